@@ -11,11 +11,13 @@ import (
 	"syscall"
 	"time"
 
+	"etamonitor/internal/agent"
 	"etamonitor/internal/api"
 	"etamonitor/internal/cli"
 	"etamonitor/internal/config"
 	"etamonitor/internal/db"
 	"etamonitor/internal/monitor"
+	"etamonitor/internal/webhooks"
 	"etamonitor/internal/websocket"
 
 	"github.com/gin-gonic/gin"
@@ -28,6 +30,12 @@ func main() {
 	log.Printf("Git Commit: %s\n", config.GitCommit)
 	log.Println("========================")
 
+	// 远程探测节点模式: etamonitor agent -server <地址> -token <令牌>
+	if len(os.Args) > 1 && os.Args[1] == "agent" {
+		runAgent(os.Args[2:])
+		return
+	}
+
 	// 解析命令行参数
 	setadmin := flag.Bool("setadmin", false, "设置管理员账户")
 	configPath := flag.String("c", "", "配置文件路径")
@@ -75,13 +83,19 @@ func main() {
 		log.Printf("Warning: Failed to set trusted proxies: %v", err)
 	}
 
+	// 创建服务器监控，API需要查询其运行状态
+	monitorService := monitor.NewService(database, cfg)
+
 	// 初始化API路由
-	api.SetupRoutes(router, database, cfg)
+	api.SetupRoutes(router, database, cfg, monitorService)
 
 	// 启动服务器监控
-	monitorService := monitor.NewService(database, cfg)
 	go monitorService.Start()
 
+	// 启动Webhook分发，将监控事件投递给外部服务
+	webhookDispatcher := webhooks.NewDispatcher(database, cfg.EncryptionKey, cfg.PublicURL)
+	go webhookDispatcher.Start()
+
 	// 创建HTTP服务器
 	address := fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)
 	server := &http.Server{
@@ -109,6 +123,7 @@ func main() {
 
 	// 停止监控服务
 	monitorService.Stop()
+	webhookDispatcher.Stop()
 
 	// 给服务器5秒时间来完成现有请求
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -120,3 +135,18 @@ func main() {
 
 	log.Println("Server exited")
 }
+
+// runAgent 以远程探测节点模式运行，收到中断信号时退出
+func runAgent(args []string) {
+	agentConfig, err := agent.ParseConfig(args)
+	if err != nil {
+		log.Fatal("Invalid agent configuration:", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := agent.New(agentConfig).Run(ctx); err != nil {
+		log.Fatal("Agent exited with error:", err)
+	}
+}
@@ -0,0 +1,210 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"etamonitor/internal/services"
+)
+
+// Config 探测节点配置
+type Config struct {
+	ServerURL   string        // 中心节点地址，如 https://monitor.example.com
+	Token       string        // 在中心节点创建探测节点时获得的令牌
+	Interval    time.Duration // 检查间隔
+	Timeout     time.Duration // 单次探测超时
+	Concurrency int           // 最大并发探测数
+}
+
+// ParseConfig 解析 agent 子命令的参数，未指定时从环境变量读取
+func ParseConfig(args []string) (*Config, error) {
+	cfg := &Config{}
+
+	fs := flag.NewFlagSet("agent", flag.ContinueOnError)
+	fs.StringVar(&cfg.ServerURL, "server", os.Getenv("ETAMONITOR_SERVER"), "中心节点地址")
+	fs.StringVar(&cfg.Token, "token", os.Getenv("AGENT_TOKEN"), "探测节点令牌")
+	fs.DurationVar(&cfg.Interval, "interval", 30*time.Second, "检查间隔")
+	fs.DurationVar(&cfg.Timeout, "timeout", 10*time.Second, "单次探测超时")
+	fs.IntVar(&cfg.Concurrency, "concurrency", 10, "最大并发探测数")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg.ServerURL = strings.TrimRight(cfg.ServerURL, "/")
+	if cfg.ServerURL == "" {
+		return nil, fmt.Errorf("未指定中心节点地址 (-server 或 ETAMONITOR_SERVER)")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("未指定探测节点令牌 (-token 或 AGENT_TOKEN)")
+	}
+	if cfg.Interval < 5*time.Second {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	return cfg, nil
+}
+
+// Agent 远程探测节点，使用与中心节点相同的探测器检查服务器并上报结果
+type Agent struct {
+	config *Config
+	client *http.Client
+}
+
+// New 创建探测节点
+func New(cfg *Config) *Agent {
+	return &Agent{
+		config: cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Run 按间隔循环执行检查，直到ctx被取消
+func (a *Agent) Run(ctx context.Context) error {
+	log.Printf("Agent started, reporting to %s every %v", a.config.ServerURL, a.config.Interval)
+
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.runOnce(ctx); err != nil {
+			log.Printf("Agent round failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Agent stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce 获取检查目标、执行探测并上报结果
+func (a *Agent) runOnce(ctx context.Context) error {
+	var targets []Target
+	if err := a.request(ctx, http.MethodGet, "/api/agent/targets", nil, &targets); err != nil {
+		return fmt.Errorf("获取检查目标失败: %w", err)
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	report := Report{Results: a.probeAll(ctx, targets)}
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	if err := a.request(ctx, http.MethodPost, "/api/agent/results", report, nil); err != nil {
+		return fmt.Errorf("上报检查结果失败: %w", err)
+	}
+	log.Printf("Reported %d results", len(report.Results))
+	return nil
+}
+
+// probeAll 并发探测所有目标
+func (a *Agent) probeAll(ctx context.Context, targets []Target) []Result {
+	results := make([]Result, len(targets))
+	semaphore := make(chan struct{}, a.config.Concurrency)
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			results[i] = a.probe(ctx, target)
+		}(i, target)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// probe 探测单个目标
+func (a *Agent) probe(ctx context.Context, target Target) Result {
+	ctx, cancel := context.WithTimeout(ctx, a.config.Timeout)
+	defer cancel()
+
+	result := Result{ServerID: target.ServerID, Ping: -1}
+
+	prober := services.ProberFor(target.Type)
+	serverInfo, err := prober.Probe(ctx, services.ProbeTarget{
+		Host:              target.Address,
+		Port:              target.Port,
+		HandshakeHost:     target.HandshakeHost,
+		HandshakeProtocol: target.HandshakeProtocol,
+		ProxyProtocol:     target.ProxyProtocol,
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Online = true
+	result.Ping = serverInfo.Ping
+	result.PlayersOnline = serverInfo.Players.Online
+	result.MaxPlayers = serverInfo.Players.Max
+	result.Version = serverInfo.Version.Name
+	return result
+}
+
+// request 调用中心节点API，响应格式为 {"success": bool, "data": ..., "error": {...}}
+func (a *Agent) request(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.config.ServerURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+a.config.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		Success bool            `json:"success"`
+		Data    json.RawMessage `json:"data"`
+		Error   struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&envelope); err != nil {
+		return fmt.Errorf("解析响应失败 (HTTP %d): %v", resp.StatusCode, err)
+	}
+	if !envelope.Success {
+		return fmt.Errorf("%s: %s", envelope.Error.Code, envelope.Error.Message)
+	}
+
+	if out != nil && len(envelope.Data) > 0 {
+		return json.Unmarshal(envelope.Data, out)
+	}
+	return nil
+}
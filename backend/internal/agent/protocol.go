@@ -0,0 +1,28 @@
+package agent
+
+// Target 中心节点下发给探测节点的检查目标
+type Target struct {
+	ServerID          uint   `json:"server_id"`
+	Type              string `json:"type"`
+	Address           string `json:"address"`
+	Port              int    `json:"port"`
+	HandshakeHost     string `json:"handshake_host,omitempty"`
+	HandshakeProtocol int    `json:"handshake_protocol,omitempty"`
+	ProxyProtocol     string `json:"proxy_protocol,omitempty"`
+}
+
+// Result 探测节点对单个服务器的检查结果
+type Result struct {
+	ServerID      uint   `json:"server_id"`
+	Online        bool   `json:"online"`
+	Ping          int    `json:"ping"`
+	PlayersOnline int    `json:"players_online"`
+	MaxPlayers    int    `json:"max_players"`
+	Version       string `json:"version,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// Report 探测节点一轮检查后上报的全部结果
+type Report struct {
+	Results []Result `json:"results"`
+}
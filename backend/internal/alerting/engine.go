@@ -0,0 +1,197 @@
+package alerting
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"etamonitor/internal/events"
+	"etamonitor/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ruleKey 标识某条规则在某个服务器上的评估状态
+type ruleKey struct {
+	ruleID   uint
+	serverID uint
+}
+
+// ruleState 规则条件连续满足的情况
+type ruleState struct {
+	count int       // 连续满足条件的检查次数
+	since time.Time // 条件开始满足的时间
+}
+
+// Engine 在每次检查后评估告警规则，管理告警的触发和恢复
+// 同一规则和服务器在恢复之前不会重复触发告警
+type Engine struct {
+	db *gorm.DB
+
+	mu     sync.Mutex
+	states map[ruleKey]*ruleState
+	firing map[ruleKey]bool
+}
+
+// NewEngine 创建告警引擎，并载入数据库中仍在触发的告警
+// 离线规则的计时从未结束的停机事件恢复，重启不会让持续中的停机重新计时
+func NewEngine(db *gorm.DB) *Engine {
+	e := &Engine{
+		db:     db,
+		states: make(map[ruleKey]*ruleState),
+		firing: make(map[ruleKey]bool),
+	}
+
+	var alerts []models.Alert
+	if err := db.Select("rule_id, server_id").Where("state = ?", StateFiring).Find(&alerts).Error; err != nil {
+		log.Printf("Failed to load firing alerts: %v", err)
+	}
+	for _, alert := range alerts {
+		e.firing[ruleKey{alert.RuleID, alert.ServerID}] = true
+	}
+
+	e.restoreOfflineStates()
+	return e
+}
+
+// restoreOfflineStates 为仍在停机的服务器恢复离线规则的状态，条件从停机开始时满足
+// 有生效时段的规则在时段外会重新计时，无法从停机开始时间推算，不做恢复
+func (e *Engine) restoreOfflineStates() {
+	var incidents []models.Incident
+	if err := e.db.Where("ended_at IS NULL").Find(&incidents).Error; err != nil {
+		log.Printf("Failed to load open incidents: %v", err)
+		return
+	}
+	if len(incidents) == 0 {
+		return
+	}
+
+	var rules []models.AlertRule
+	if err := e.db.Where("enabled = ? AND type = ? AND active_from = ?", true, RuleOffline, "").Find(&rules).Error; err != nil {
+		log.Printf("Failed to load offline alert rules: %v", err)
+		return
+	}
+
+	for _, incident := range incidents {
+		for _, rule := range rules {
+			if rule.ServerID != nil && *rule.ServerID != incident.ServerID {
+				continue
+			}
+			key := ruleKey{rule.ID, incident.ServerID}
+			if e.firing[key] {
+				continue
+			}
+			// 停机事件在确认离线后才开始，视为已满足连续检查次数
+			e.states[key] = &ruleState{count: max(rule.Checks, 1) - 1, since: incident.StartedAt}
+		}
+	}
+}
+
+// Evaluate 根据一次检查结果评估适用于该服务器的所有启用规则
+func (e *Engine) Evaluate(check Check) {
+	var rules []models.AlertRule
+	if err := e.db.Where("enabled = ? AND (server_id IS NULL OR server_id = ?)", true, check.Server.ID).
+		Find(&rules).Error; err != nil {
+		log.Printf("Failed to load alert rules for %s: %v", check.Server.Name, err)
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := check.Stat.Timestamp
+	evaluated := make(map[uint]bool, len(rules))
+	for i := range rules {
+		rule := &rules[i]
+		evaluated[rule.ID] = true
+		key := ruleKey{rule.ID, check.Server.ID}
+
+		matched, value, message := evaluate(rule, check)
+		if !matched {
+			delete(e.states, key)
+			if e.firing[key] {
+				e.resolve(key, now)
+			}
+			continue
+		}
+
+		state := e.states[key]
+		if state == nil {
+			state = &ruleState{since: now}
+			e.states[key] = state
+		}
+		state.count++
+
+		if !e.firing[key] && state.count >= max(rule.Checks, 1) &&
+			now.Sub(state.since) >= time.Duration(rule.Duration)*time.Minute {
+			e.fire(key, rule, value, message, now)
+		}
+	}
+
+	// 已删除、停用或不再适用于该服务器的规则，恢复其告警并清除状态
+	for key := range e.states {
+		if key.serverID == check.Server.ID && !evaluated[key.ruleID] {
+			delete(e.states, key)
+		}
+	}
+	for key := range e.firing {
+		if key.serverID == check.Server.ID && !evaluated[key.ruleID] {
+			e.resolve(key, now)
+		}
+	}
+}
+
+// fire 创建触发中的告警，数据库中已有同一规则和服务器的触发中告警时不重复创建
+func (e *Engine) fire(key ruleKey, rule *models.AlertRule, value float64, message string, now time.Time) {
+	var existing int64
+	e.db.Model(&models.Alert{}).
+		Where("rule_id = ? AND server_id = ? AND state = ?", key.ruleID, key.serverID, StateFiring).
+		Count(&existing)
+	if existing > 0 {
+		e.firing[key] = true
+		return
+	}
+
+	alert := models.Alert{
+		RuleID:   key.ruleID,
+		ServerID: key.serverID,
+		State:    StateFiring,
+		Message:  message,
+		Value:    value,
+		FiredAt:  now,
+	}
+	if err := e.db.Create(&alert).Error; err != nil {
+		log.Printf("Failed to create alert for rule %s: %v", rule.Name, err)
+		return
+	}
+	e.firing[key] = true
+	log.Printf("Alert %d firing (%s): %s", alert.ID, rule.Name, message)
+
+	alert.Rule = rule
+	events.Publish(events.AlertFiring, key.serverID, alert)
+}
+
+// resolve 恢复规则在服务器上触发中的告警
+func (e *Engine) resolve(key ruleKey, now time.Time) {
+	var alerts []models.Alert
+	if err := e.db.Preload("Rule").
+		Where("rule_id = ? AND server_id = ? AND state = ?", key.ruleID, key.serverID, StateFiring).
+		Find(&alerts).Error; err != nil {
+		log.Printf("Failed to load firing alerts for rule %d on server %d: %v", key.ruleID, key.serverID, err)
+		return
+	}
+
+	for i := range alerts {
+		alert := &alerts[i]
+		if err := e.db.Model(alert).Updates(map[string]interface{}{
+			"state":       StateResolved,
+			"resolved_at": now,
+		}).Error; err != nil {
+			log.Printf("Failed to resolve alert %d: %v", alert.ID, err)
+			return
+		}
+		log.Printf("Alert %d resolved: %s", alert.ID, alert.Message)
+		events.Publish(events.AlertResolved, key.serverID, alert)
+	}
+	delete(e.firing, key)
+}
@@ -0,0 +1,152 @@
+package alerting
+
+import (
+	"fmt"
+	"time"
+
+	"etamonitor/internal/models"
+)
+
+// 告警规则类型
+const (
+	RuleOffline        = "offline"         // 服务器离线
+	RulePingAbove      = "ping_above"      // 延迟超过阈值(ms)
+	RulePlayersAbove   = "players_above"   // 在线玩家数超过阈值
+	RulePlayersZero    = "players_zero"    // 在线服务器没有玩家，通常配合生效时段使用
+	RuleVersionChanged = "version_changed" // 服务器版本发生变化
+)
+
+// 告警状态
+const (
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+// 规则参数的上限
+const (
+	maxChecks   = 100
+	maxDuration = 7 * 24 * 60
+)
+
+// Check 一次检查的结果，用于评估告警规则
+type Check struct {
+	Server          *models.Server
+	Stat            *models.ServerStat
+	Status          string // 本次检查后服务器的状态
+	Reachable       bool   // 本次检查是否成功访问服务器
+	PreviousVersion string // 本次检查之前记录的服务器版本
+}
+
+// Validate 校验告警规则的配置
+func Validate(rule *models.AlertRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("规则名称不能为空")
+	}
+
+	switch rule.Type {
+	case RuleOffline, RulePlayersZero, RuleVersionChanged:
+	case RulePingAbove, RulePlayersAbove:
+		if rule.Threshold < 0 {
+			return fmt.Errorf("阈值不能为负数")
+		}
+	default:
+		return fmt.Errorf("不支持的规则类型: %s", rule.Type)
+	}
+
+	if rule.Checks < 0 || rule.Checks > maxChecks {
+		return fmt.Errorf("连续检查次数需在0到%d之间", maxChecks)
+	}
+	if rule.Duration < 0 || rule.Duration > maxDuration {
+		return fmt.Errorf("持续时间需在0到%d分钟之间", maxDuration)
+	}
+
+	if (rule.ActiveFrom == "") != (rule.ActiveTo == "") {
+		return fmt.Errorf("生效时段需要同时设置开始和结束时间")
+	}
+	if rule.ActiveFrom != "" {
+		if _, err := parseClock(rule.ActiveFrom); err != nil {
+			return err
+		}
+		if _, err := parseClock(rule.ActiveTo); err != nil {
+			return err
+		}
+	}
+	if _, err := location(rule.Timezone); err != nil {
+		return fmt.Errorf("无效的时区: %s", rule.Timezone)
+	}
+	return nil
+}
+
+// evaluate 判断检查结果是否满足规则条件，返回观测值及告警消息
+// 维护窗口内的检查、暂停监控的服务器及生效时段之外不满足任何条件
+func evaluate(rule *models.AlertRule, check Check) (bool, float64, string) {
+	if check.Stat.Maintenance || check.Status == "paused" || !activeAt(rule, check.Stat.Timestamp) {
+		return false, 0, ""
+	}
+
+	server, stat := check.Server, check.Stat
+	switch rule.Type {
+	case RuleOffline:
+		if check.Status == "offline" {
+			if rule.Duration > 0 {
+				return true, 0, fmt.Sprintf("%s 已离线超过%d分钟", server.Name, rule.Duration)
+			}
+			return true, 0, fmt.Sprintf("%s 已离线", server.Name)
+		}
+	case RulePingAbove:
+		if check.Reachable && float64(stat.Ping) > rule.Threshold {
+			return true, float64(stat.Ping), fmt.Sprintf("%s 延迟为%dms，超过%gms", server.Name, stat.Ping, rule.Threshold)
+		}
+	case RulePlayersAbove:
+		if check.Reachable && float64(stat.PlayersOnline) > rule.Threshold {
+			return true, float64(stat.PlayersOnline), fmt.Sprintf("%s 在线玩家%d人，超过%g人", server.Name, stat.PlayersOnline, rule.Threshold)
+		}
+	case RulePlayersZero:
+		if check.Reachable && stat.PlayersOnline == 0 {
+			return true, 0, fmt.Sprintf("%s 在线玩家降为0", server.Name)
+		}
+	case RuleVersionChanged:
+		// 版本变化只在发生变化的那次检查满足条件，告警在下一次检查时恢复
+		if check.Reachable && check.PreviousVersion != "" && stat.Version != check.PreviousVersion {
+			return true, 0, fmt.Sprintf("%s 版本由 %s 变为 %s", server.Name, check.PreviousVersion, stat.Version)
+		}
+	}
+	return false, 0, ""
+}
+
+// activeAt 判断规则在 t 时刻是否处于生效时段，结束时间早于开始时间时表示跨越午夜
+func activeAt(rule *models.AlertRule, t time.Time) bool {
+	if rule.ActiveFrom == "" {
+		return true
+	}
+	from, err1 := parseClock(rule.ActiveFrom)
+	to, err2 := parseClock(rule.ActiveTo)
+	loc, err3 := location(rule.Timezone)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return true
+	}
+
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	if from <= to {
+		return minute >= from && minute < to
+	}
+	return minute >= from || minute < to
+}
+
+// parseClock 解析 HH:MM 格式的时间，返回当天的分钟数
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("无效的时间 %s，应为 HH:MM 格式", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// location 解析时区，为空时使用本地时区
+func location(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
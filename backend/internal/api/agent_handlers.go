@@ -0,0 +1,181 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"etamonitor/internal/agent"
+	"etamonitor/internal/auth"
+	"etamonitor/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// =================================================================================
+// Agent Handlers (远程探测节点)
+//
+// 此文件包含远程探测节点相关的API处理器。
+// 节点管理和查看检查结果需要用户认证，目标获取与结果上报使用探测节点令牌认证。
+// =================================================================================
+
+// handleGetAgents 获取探测节点列表 (需要认证)
+func handleGetAgents(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var agents []models.Agent
+		db.Order("name").Find(&agents)
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": agents})
+	}
+}
+
+// handleCreateAgent 创建探测节点，令牌只在创建时返回一次 (需要认证)
+func handleCreateAgent(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Name   string `json:"name" binding:"required"`
+			Region string `json:"region"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": err.Error()}})
+			return
+		}
+
+		token, err := auth.GenerateAgentToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "INTERNAL_ERROR", "message": "生成令牌失败"}})
+			return
+		}
+
+		newAgent := models.Agent{
+			Name:      req.Name,
+			Region:    req.Region,
+			TokenHash: auth.HashAgentToken(token),
+		}
+		if err := db.Create(&newAgent).Error; err != nil {
+			c.JSON(http.StatusConflict, gin.H{"success": false, "error": map[string]interface{}{"code": "CONFLICT", "message": "探测节点名称已存在"}})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"success": true,
+			"data": gin.H{
+				"agent": newAgent,
+				"token": token,
+			},
+		})
+	}
+}
+
+// handleDeleteAgent 删除探测节点及其上报的结果 (需要认证)
+func handleDeleteAgent(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		result := db.Delete(&models.Agent{}, id)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "删除探测节点失败"}})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "探测节点不存在"}})
+			return
+		}
+		db.Where("agent_id = ?", id).Delete(&models.AgentResult{})
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "探测节点删除成功"})
+	}
+}
+
+// handleAgentTargets 返回探测节点需要检查的服务器 (探测节点令牌认证)
+func handleAgentTargets(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var servers []models.Server
+		db.Select("id", "type", "address", "port", "handshake_host", "handshake_protocol", "proxy_protocol").Find(&servers)
+
+		targets := make([]agent.Target, 0, len(servers))
+		for _, server := range servers {
+			targets = append(targets, agent.Target{
+				ServerID:          server.ID,
+				Type:              server.Type,
+				Address:           server.Address,
+				Port:              server.Port,
+				HandshakeHost:     server.HandshakeHost,
+				HandshakeProtocol: server.HandshakeProtocol,
+				ProxyProtocol:     server.ProxyProtocol,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": targets})
+	}
+}
+
+// handleAgentReport 接收探测节点上报的检查结果 (探测节点令牌认证)
+func handleAgentReport(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var report agent.Report
+		if err := c.ShouldBindJSON(&report); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": err.Error()}})
+			return
+		}
+		if len(report.Results) == 0 {
+			c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"accepted": 0}})
+			return
+		}
+
+		// 只接受仍然存在的服务器的结果
+		var serverIDs []uint
+		db.Model(&models.Server{}).Pluck("id", &serverIDs)
+		known := make(map[uint]bool, len(serverIDs))
+		for _, id := range serverIDs {
+			known[id] = true
+		}
+
+		agentID := c.GetUint("agent_id")
+		now := time.Now()
+		results := make([]models.AgentResult, 0, len(report.Results))
+		for _, r := range report.Results {
+			if !known[r.ServerID] {
+				continue
+			}
+			results = append(results, models.AgentResult{
+				AgentID:       agentID,
+				ServerID:      r.ServerID,
+				Online:        r.Online,
+				Ping:          r.Ping,
+				PlayersOnline: r.PlayersOnline,
+				MaxPlayers:    r.MaxPlayers,
+				Version:       r.Version,
+				Error:         r.Error,
+				Timestamp:     now,
+			})
+		}
+
+		if len(results) > 0 {
+			if err := db.Create(&results).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "保存检查结果失败"}})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"accepted": len(results)}})
+	}
+}
+
+// handleGetServerAgentResults 获取各探测节点对服务器的最新检查结果 (需要认证)
+func handleGetServerAgentResults(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		server, ok := loadServerByParam(c, db)
+		if !ok {
+			return
+		}
+
+		// 每个探测节点只取最新的一条结果
+		latest := db.Model(&models.AgentResult{}).
+			Select("MAX(id)").
+			Where("server_id = ?", server.ID).
+			Group("agent_id")
+
+		var results []models.AgentResult
+		db.Preload("Agent").Where("id IN (?)", latest).Order("agent_id").Find(&results)
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": results})
+	}
+}
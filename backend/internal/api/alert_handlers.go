@@ -0,0 +1,171 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"etamonitor/internal/alerting"
+	"etamonitor/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// =================================================================================
+// Alert Handlers (告警)
+//
+// 此文件包含告警规则管理和告警查询相关的API处理器，均需要用户认证。
+// 规则在每次服务器检查后由监控服务评估。
+// =================================================================================
+
+// alertRuleRequest 创建或更新告警规则的请求
+type alertRuleRequest struct {
+	Name       string  `json:"name" binding:"required"`
+	ServerID   *uint   `json:"server_id"`
+	Type       string  `json:"type" binding:"required"`
+	Threshold  float64 `json:"threshold"`
+	Checks     int     `json:"checks"`
+	Duration   int     `json:"duration"`
+	ActiveFrom string  `json:"active_from"`
+	ActiveTo   string  `json:"active_to"`
+	Timezone   string  `json:"timezone"`
+	Enabled    *bool   `json:"enabled"` // 默认启用
+}
+
+// bindAlertRule 解析并校验请求，写入 rule，失败时返回错误响应并返回false
+func bindAlertRule(c *gin.Context, db *gorm.DB, rule *models.AlertRule) bool {
+	var req alertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": err.Error()}})
+		return false
+	}
+
+	rule.Name = req.Name
+	rule.ServerID = req.ServerID
+	rule.Type = req.Type
+	rule.Threshold = req.Threshold
+	rule.Checks = req.Checks
+	rule.Duration = req.Duration
+	rule.ActiveFrom = req.ActiveFrom
+	rule.ActiveTo = req.ActiveTo
+	rule.Timezone = req.Timezone
+	rule.Enabled = req.Enabled == nil || *req.Enabled
+
+	if err := alerting.Validate(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": err.Error()}})
+		return false
+	}
+	if rule.ServerID != nil {
+		var count int64
+		db.Model(&models.Server{}).Where("id = ?", *rule.ServerID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "服务器不存在"}})
+			return false
+		}
+	}
+	return true
+}
+
+// handleGetAlertRules 获取告警规则列表 (需要认证)
+func handleGetAlertRules(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var rules []models.AlertRule
+		if err := db.Order("id").Find(&rules).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "查询告警规则失败"}})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": rules})
+	}
+}
+
+// handleCreateAlertRule 创建告警规则 (需要认证)
+func handleCreateAlertRule(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var rule models.AlertRule
+		if !bindAlertRule(c, db, &rule) {
+			return
+		}
+		if err := db.Create(&rule).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "创建告警规则失败"}})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"success": true, "data": rule})
+	}
+}
+
+// handleUpdateAlertRule 更新告警规则 (需要认证)
+// 触发中的告警在下一次检查时按新规则重新评估
+func handleUpdateAlertRule(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var rule models.AlertRule
+		if err := db.First(&rule, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "告警规则不存在"}})
+			return
+		}
+		if !bindAlertRule(c, db, &rule) {
+			return
+		}
+		if err := db.Save(&rule).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "更新告警规则失败"}})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": rule})
+	}
+}
+
+// handleDeleteAlertRule 删除告警规则及其告警记录 (需要认证)
+func handleDeleteAlertRule(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var rule models.AlertRule
+		if err := db.First(&rule, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "告警规则不存在"}})
+			return
+		}
+
+		// 规则和它的告警一起删除
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("rule_id = ?", rule.ID).Delete(&models.Alert{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&rule).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "删除告警规则失败"}})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "告警规则删除成功"})
+	}
+}
+
+// handleGetAlerts 获取告警列表，可按服务器、规则和状态 (firing, resolved) 筛选 (需要认证)
+func handleGetAlerts(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 || limit > 200 {
+			limit = 50
+		}
+
+		query := db.Preload("Rule").Preload("Server").Order("fired_at DESC").Limit(limit)
+		if serverID := c.Query("server_id"); serverID != "" {
+			query = query.Where("server_id = ?", serverID)
+		}
+		if ruleID := c.Query("rule_id"); ruleID != "" {
+			query = query.Where("rule_id = ?", ruleID)
+		}
+		switch state := c.Query("state"); state {
+		case alerting.StateFiring, alerting.StateResolved:
+			query = query.Where("state = ?", state)
+		case "":
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "无效的告警状态"}})
+			return
+		}
+
+		var alerts []models.Alert
+		if err := query.Find(&alerts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "查询告警失败"}})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": alerts})
+	}
+}
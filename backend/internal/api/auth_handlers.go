@@ -1,14 +1,19 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"path/filepath"
 	"time"
 
 	"etamonitor/internal/auth"
 	"etamonitor/internal/db"
+	"etamonitor/internal/maintenance"
 	"etamonitor/internal/models"
+	"etamonitor/internal/monitor"
 	"etamonitor/internal/services"
+	"etamonitor/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -217,14 +222,27 @@ func handleChangePassword(db *gorm.DB) gin.HandlerFunc {
 }
 
 // handleCreateServer 创建服务器 (需要认证)
-func handleCreateServer(db *gorm.DB) gin.HandlerFunc {
+func handleCreateServer(db *gorm.DB, encryptionKey string, monitorService *monitor.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req struct {
-			Name        string `json:"name" binding:"required"`
-			Address     string `json:"address" binding:"required"`
-			Port        int    `json:"port"`
-			Type        string `json:"type"`
-			Description string `json:"description"`
+			Name              string `json:"name" binding:"required"`
+			Address           string `json:"address" binding:"required"`
+			Port              int    `json:"port"`
+			Type              string `json:"type"`
+			Description       string `json:"description"`
+			PlayerSource      string `json:"player_source"`
+			QueryPort         int    `json:"query_port"`
+			RconPort          int    `json:"rcon_port"`
+			RconPassword      string `json:"rcon_password"`
+			HandshakeHost     string `json:"handshake_host"`
+			HandshakeProtocol int    `json:"handshake_protocol"`
+			ProxyProtocol     string `json:"proxy_protocol"`
+			LoginCheck        bool   `json:"login_check"`
+			LoginUsername     string `json:"login_username"`
+			CheckInterval     int    `json:"check_interval"`
+			CheckTimeout      int    `json:"check_timeout"`
+			RetryCount        int    `json:"retry_count"`
+			FailureThreshold  int    `json:"failure_threshold"`
 		}
 
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -238,27 +256,72 @@ func handleCreateServer(db *gorm.DB) gin.HandlerFunc {
 		if req.Type == "" {
 			req.Type = "auto"
 		}
+		if _, ok := services.GetProber(req.Type); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "不支持的服务器类型", "details": services.ProberNames()}})
+			return
+		}
+		if req.PlayerSource == "" {
+			req.PlayerSource = "status"
+		}
+		if !isValidPlayerSource(req.PlayerSource) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "无效的玩家列表来源"}})
+			return
+		}
+		if !services.IsValidProxyProtocol(req.ProxyProtocol) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "无效的PROXY协议版本"}})
+			return
+		}
+		if !services.IsValidLoginUsername(req.LoginUsername) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "无效的登录检查玩家名"}})
+			return
+		}
+		if message := validateCheckPolicy(req.CheckInterval, req.CheckTimeout, req.RetryCount, req.FailureThreshold); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": message}})
+			return
+		}
 
 		server := models.Server{
-			Name:        req.Name,
-			Address:     req.Address,
-			Port:        req.Port,
-			Type:        req.Type,
-			Description: req.Description,
-			Status:      "checking",
+			Name:              req.Name,
+			Address:           req.Address,
+			Port:              req.Port,
+			Type:              req.Type,
+			Description:       req.Description,
+			PlayerSource:      req.PlayerSource,
+			QueryPort:         req.QueryPort,
+			RconPort:          req.RconPort,
+			HandshakeHost:     req.HandshakeHost,
+			HandshakeProtocol: req.HandshakeProtocol,
+			ProxyProtocol:     req.ProxyProtocol,
+			LoginCheck:        req.LoginCheck,
+			LoginUsername:     req.LoginUsername,
+			CheckInterval:     req.CheckInterval,
+			CheckTimeout:      req.CheckTimeout,
+			RetryCount:        req.RetryCount,
+			FailureThreshold:  req.FailureThreshold,
+			Status:            "checking",
+		}
+
+		encrypted, err := auth.EncryptSecret(req.RconPassword, encryptionKey)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "INTERNAL_ERROR", "message": "RCON密码加密失败"}})
+			return
 		}
+		server.RconPassword = encrypted
 
 		if err := db.Create(&server).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "创建服务器失败"}})
 			return
 		}
 
+		// 立即检查新服务器，结果通过WebSocket推送
+		monitorService.EnqueueCheck(server.ID)
+
 		c.JSON(http.StatusCreated, gin.H{"success": true, "data": server})
 	}
 }
 
 // handleUpdateServer 更新服务器 (需要认证)
-func handleUpdateServer(db *gorm.DB) gin.HandlerFunc {
+func handleUpdateServer(db *gorm.DB, encryptionKey string, monitorService *monitor.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 		var server models.Server
@@ -269,8 +332,25 @@ func handleUpdateServer(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		var req struct {
-			Name        *string `json:"name"`
-			Description *string `json:"description"`
+			Name              *string `json:"name"`
+			Address           *string `json:"address"`
+			Port              *int    `json:"port"`
+			Type              *string `json:"type"`
+			Description       *string `json:"description"`
+			PlayerSource      *string `json:"player_source"`
+			QueryPort         *int    `json:"query_port"`
+			RconPort          *int    `json:"rcon_port"`
+			RconPassword      *string `json:"rcon_password"`
+			HandshakeHost     *string `json:"handshake_host"`
+			HandshakeProtocol *int    `json:"handshake_protocol"`
+			ProxyProtocol     *string `json:"proxy_protocol"`
+			LoginCheck        *bool   `json:"login_check"`
+			LoginUsername     *string `json:"login_username"`
+			CheckInterval     *int    `json:"check_interval"`
+			CheckTimeout      *int    `json:"check_timeout"`
+			RetryCount        *int    `json:"retry_count"`
+			FailureThreshold  *int    `json:"failure_threshold"`
+			Paused            *bool   `json:"paused"`
 		}
 
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -278,41 +358,378 @@ func handleUpdateServer(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		// 连接相关的配置在修改前的值，变化时需要重新检查
+		probeTarget := services.ServerProbeTarget(&server)
+		serverType := server.Type
+		paused := server.Paused
+
 		if req.Name != nil {
 			server.Name = *req.Name
 		}
+		if req.Address != nil {
+			if *req.Address == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "服务器地址不能为空"}})
+				return
+			}
+			server.Address = *req.Address
+		}
+		if req.Port != nil {
+			if *req.Port <= 0 || *req.Port > 65535 {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "无效的端口"}})
+				return
+			}
+			server.Port = *req.Port
+		}
+		if req.Type != nil {
+			if _, ok := services.GetProber(*req.Type); !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "不支持的服务器类型", "details": services.ProberNames()}})
+				return
+			}
+			server.Type = *req.Type
+		}
 		if req.Description != nil {
 			server.Description = *req.Description
 		}
+		if req.PlayerSource != nil {
+			if !isValidPlayerSource(*req.PlayerSource) {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "无效的玩家列表来源"}})
+				return
+			}
+			server.PlayerSource = *req.PlayerSource
+		}
+		if req.QueryPort != nil {
+			server.QueryPort = *req.QueryPort
+		}
+		if req.RconPort != nil {
+			server.RconPort = *req.RconPort
+		}
+		if req.HandshakeHost != nil {
+			server.HandshakeHost = *req.HandshakeHost
+		}
+		if req.HandshakeProtocol != nil {
+			server.HandshakeProtocol = *req.HandshakeProtocol
+		}
+		if req.ProxyProtocol != nil {
+			if !services.IsValidProxyProtocol(*req.ProxyProtocol) {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "无效的PROXY协议版本"}})
+				return
+			}
+			server.ProxyProtocol = *req.ProxyProtocol
+		}
+		if req.LoginCheck != nil {
+			server.LoginCheck = *req.LoginCheck
+			if !server.LoginCheck {
+				server.JoinStatus, server.JoinMessage = "", ""
+			}
+		}
+		if req.LoginUsername != nil {
+			if !services.IsValidLoginUsername(*req.LoginUsername) {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "无效的登录检查玩家名"}})
+				return
+			}
+			server.LoginUsername = *req.LoginUsername
+		}
+		if req.CheckInterval != nil {
+			server.CheckInterval = *req.CheckInterval
+		}
+		if req.CheckTimeout != nil {
+			server.CheckTimeout = *req.CheckTimeout
+		}
+		if req.RetryCount != nil {
+			server.RetryCount = *req.RetryCount
+		}
+		if req.FailureThreshold != nil {
+			server.FailureThreshold = *req.FailureThreshold
+		}
+		if req.Paused != nil {
+			server.Paused = *req.Paused
+		}
+		if message := validateCheckPolicy(server.CheckInterval, server.CheckTimeout, server.RetryCount, server.FailureThreshold); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": message}})
+			return
+		}
+		if req.RconPassword != nil {
+			encrypted, err := auth.EncryptSecret(*req.RconPassword, encryptionKey)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "INTERNAL_ERROR", "message": "RCON密码加密失败"}})
+				return
+			}
+			server.RconPassword = encrypted
+		}
+
+		// 连接配置变化或恢复监控后，之前的状态和失败计数不再适用
+		// 暂停监控时同样立即检查，由监控服务将服务器标记为已暂停并结束玩家会话
+		recheck := services.ServerProbeTarget(&server) != probeTarget || server.Type != serverType || server.Paused != paused
+		if recheck && !server.Paused {
+			server.Status = "checking"
+			server.ConsecutiveFailures = 0
+		}
 
 		if err := db.Save(&server).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "更新服务器失败"}})
 			return
 		}
 
+		if recheck {
+			monitorService.EnqueueCheck(server.ID)
+		}
+
 		c.JSON(http.StatusOK, gin.H{"success": true, "data": server})
 	}
 }
 
-// handleDeleteServer 删除服务器 (需要认证)
+// handleGetServerSettings 获取服务器的连接和检查设置，其中部分字段不在公开接口中返回 (需要认证)
+func handleGetServerSettings(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var server models.Server
+		if err := db.First(&server, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "服务器不存在"}})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"id":                   server.ID,
+				"player_source":        server.PlayerSource,
+				"query_port":           server.QueryPort,
+				"rcon_port":            server.RconPort,
+				"rcon_password_set":    server.RconPassword != "",
+				"handshake_host":       server.HandshakeHost,
+				"handshake_protocol":   server.HandshakeProtocol,
+				"proxy_protocol":       server.ProxyProtocol,
+				"login_check":          server.LoginCheck,
+				"login_username":       server.LoginUsername,
+				"check_interval":       server.CheckInterval,
+				"check_timeout":        server.CheckTimeout,
+				"retry_count":          server.RetryCount,
+				"failure_threshold":    server.FailureThreshold,
+				"consecutive_failures": server.ConsecutiveFailures,
+			},
+		})
+	}
+}
+
+// handleDeleteServer 删除服务器及其关联数据，并从Webhook的服务器筛选中移除 (需要认证)
 func handleDeleteServer(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
-		result := db.Delete(&models.Server{}, id)
-		if result.Error != nil {
+		var server models.Server
+		if err := db.First(&server, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "服务器不存在"}})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Delete(&server).Error; err != nil {
+				return err
+			}
+			for _, model := range []interface{}{
+				&models.ServerStat{},
+				&models.ServerEndpoint{},
+				&models.PlayerSession{},
+				&models.PlayerActivity{},
+				&models.ServerEvent{},
+				&models.FaviconChange{},
+				&models.MaintenanceWindow{},
+				&models.Incident{},
+				&models.AlertRule{},
+				&models.Alert{},
+				&models.AgentResult{},
+			} {
+				if err := tx.Where("server_id = ?", server.ID).Delete(model).Error; err != nil {
+					return err
+				}
+			}
+			return webhooks.RemoveServer(tx, server.ID)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "删除服务器失败"}})
 			return
 		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "服务器删除成功"})
+	}
+}
+
+// handleCreateServerEndpoint 为服务器添加附加端点 (需要认证)
+func handleCreateServerEndpoint(db *gorm.DB, monitorService *monitor.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var server models.Server
+		if err := db.First(&server, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "服务器不存在"}})
+			return
+		}
+
+		var req struct {
+			Edition string `json:"edition" binding:"required"`
+			Host    string `json:"host"`
+			Port    int    `json:"port"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": err.Error()}})
+			return
+		}
+
+		prober, ok := services.GetProber(req.Edition)
+		if !ok || req.Edition == "auto" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "不支持的端点类型"}})
+			return
+		}
+		if req.Port == 0 {
+			req.Port = prober.DefaultPort()
+		}
+		if req.Port < 0 || req.Port > 65535 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "无效的端口"}})
+			return
+		}
+
+		endpoint := models.ServerEndpoint{
+			ServerID: server.ID,
+			Edition:  req.Edition,
+			Host:     req.Host,
+			Port:     req.Port,
+			Status:   "checking",
+		}
+		if err := db.Create(&endpoint).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "创建端点失败"}})
+			return
+		}
+
+		// 立即检查新端点
+		monitorService.EnqueueCheck(server.ID)
+
+		c.JSON(http.StatusCreated, gin.H{"success": true, "data": endpoint})
+	}
+}
+
+// handleUpdateServerEndpoint 更新服务器的附加端点 (需要认证)
+func handleUpdateServerEndpoint(db *gorm.DB, monitorService *monitor.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var endpoint models.ServerEndpoint
+		if err := db.Where("id = ? AND server_id = ?", c.Param("endpointId"), c.Param("id")).First(&endpoint).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "端点不存在"}})
+			return
+		}
+
+		var req struct {
+			Edition *string `json:"edition"`
+			Host    *string `json:"host"`
+			Port    *int    `json:"port"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "请求参数验证失败"}})
+			return
+		}
+
+		if req.Edition != nil {
+			if _, ok := services.GetProber(*req.Edition); !ok || *req.Edition == "auto" {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "不支持的端点类型"}})
+				return
+			}
+			endpoint.Edition = *req.Edition
+		}
+		if req.Host != nil {
+			endpoint.Host = *req.Host
+		}
+		if req.Port != nil {
+			if *req.Port <= 0 || *req.Port > 65535 {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "无效的端口"}})
+				return
+			}
+			endpoint.Port = *req.Port
+		}
+
+		if err := db.Save(&endpoint).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "更新端点失败"}})
+			return
+		}
+
+		// 端点地址或类型可能已变化，立即重新检查
+		monitorService.EnqueueCheck(endpoint.ServerID)
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": endpoint})
+	}
+}
+
+// handleDeleteServerEndpoint 删除服务器的附加端点 (需要认证)
+func handleDeleteServerEndpoint(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result := db.Where("id = ? AND server_id = ?", c.Param("endpointId"), c.Param("id")).Delete(&models.ServerEndpoint{})
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "删除端点失败"}})
+			return
+		}
 		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "端点不存在"}})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "端点删除成功"})
+	}
+}
+
+// handleCreateMaintenanceWindow 为服务器添加维护窗口 (需要认证)
+// 一次性窗口需要 starts_at 和 ends_at，周期性窗口需要 cron 和 duration (分钟)
+func handleCreateMaintenanceWindow(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var server models.Server
+		if err := db.First(&server, c.Param("id")).Error; err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "服务器不存在"}})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"success": true, "message": "服务器删除成功"})
+
+		var req struct {
+			Title    string     `json:"title"`
+			StartsAt *time.Time `json:"starts_at"`
+			EndsAt   *time.Time `json:"ends_at"`
+			Cron     string     `json:"cron"`
+			Duration int        `json:"duration"`
+			Timezone string     `json:"timezone"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": err.Error()}})
+			return
+		}
+
+		window := models.MaintenanceWindow{
+			ServerID: server.ID,
+			Title:    req.Title,
+			StartsAt: req.StartsAt,
+			EndsAt:   req.EndsAt,
+			Cron:     req.Cron,
+			Duration: req.Duration,
+			Timezone: req.Timezone,
+		}
+		if err := maintenance.Validate(&window); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": err.Error()}})
+			return
+		}
+
+		if err := db.Create(&window).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "创建维护窗口失败"}})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"success": true, "data": window})
+	}
+}
+
+// handleDeleteMaintenanceWindow 删除服务器的维护窗口 (需要认证)
+func handleDeleteMaintenanceWindow(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result := db.Where("id = ? AND server_id = ?", c.Param("windowId"), c.Param("id")).Delete(&models.MaintenanceWindow{})
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "删除维护窗口失败"}})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "维护窗口不存在"}})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "维护窗口删除成功"})
 	}
 }
 
 // handlePingServer 手动ping服务器并更新状态 (需要认证)
-func handlePingServer(db *gorm.DB) gin.HandlerFunc {
+func handlePingServer(db *gorm.DB, monitorService *monitor.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 		var server models.Server
@@ -322,22 +739,14 @@ func handlePingServer(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		var serverInfo *services.MinecraftServer
-		var err error
-		var detectedType string
+		// 与监控检查使用相同的超时，服务器设置了单独的超时时优先使用
+		ctx, cancel := context.WithTimeout(c.Request.Context(), monitorService.CheckTimeout(&server))
+		defer cancel()
 
-		switch server.Type {
-		case "java":
-			serverInfo, err = services.JavaServerPing(server.Address, server.Port)
-		case "bedrock":
-			serverInfo, err = services.BedrockServerPing(server.Address, server.Port)
-		case "auto":
-			serverInfo, detectedType, err = services.AutoDetectServer(server.Address, server.Port, 19132)
-			if err == nil && detectedType != "" {
-				db.Model(&server).Update("type", detectedType)
-			}
-		default:
-			serverInfo, err = services.JavaServerPing(server.Address, server.Port)
+		prober := services.ProberFor(server.Type)
+		serverInfo, err := prober.Probe(ctx, services.ServerProbeTarget(&server))
+		if err == nil && server.Type == "auto" && serverInfo.ServerType != services.Unknown {
+			db.Model(&server).Update("type", serverInfo.ServerType.String())
 		}
 
 		if err != nil {
@@ -360,6 +769,43 @@ func handlePingServer(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
+// isValidPlayerSource 校验玩家列表来源
+func isValidPlayerSource(source string) bool {
+	switch source {
+	case "status", "query", "rcon":
+		return true
+	default:
+		return false
+	}
+}
+
+// 单个服务器检查策略的取值范围
+const (
+	minCheckInterval = 5     // 检查间隔下限(秒)，与全局监控间隔一致
+	maxCheckInterval = 86400 // 检查间隔上限(秒)
+	maxCheckTimeout  = 30    // 探测超时上限(秒)，与全局Ping超时一致
+	maxRetryCount    = 5     // 重试次数上限
+	maxFailureCount  = 10    // 判定离线所需连续失败次数的上限
+)
+
+// validateCheckPolicy 校验服务器的检查间隔、超时、重试次数与离线判定次数，返回错误信息，合法时返回空字符串
+// 间隔、超时和离线判定次数为0表示使用全局配置
+func validateCheckPolicy(interval, timeout, retries, failureThreshold int) string {
+	if interval != 0 && (interval < minCheckInterval || interval > maxCheckInterval) {
+		return fmt.Sprintf("检查间隔需在%d到%d秒之间，0表示使用全局配置", minCheckInterval, maxCheckInterval)
+	}
+	if timeout < 0 || timeout > maxCheckTimeout {
+		return fmt.Sprintf("检查超时需在1到%d秒之间，0表示使用全局配置", maxCheckTimeout)
+	}
+	if retries < 0 || retries > maxRetryCount {
+		return fmt.Sprintf("重试次数需在0到%d之间", maxRetryCount)
+	}
+	if failureThreshold < 0 || failureThreshold > maxFailureCount {
+		return fmt.Sprintf("离线判定失败次数需在1到%d之间，0表示使用全局配置", maxFailureCount)
+	}
+	return ""
+}
+
 // -------------------------
 // Statistics & User Management (Admin-level)
 // -------------------------
@@ -1,12 +1,17 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"etamonitor/internal/config"
+	"etamonitor/internal/maintenance"
 	"etamonitor/internal/models"
 	"etamonitor/internal/services"
 
@@ -27,6 +32,7 @@ func handleGetServers(db *gorm.DB) gin.HandlerFunc {
 		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 		status := c.Query("status")
+		release := c.Query("release")
 
 		offset := (page - 1) * limit
 
@@ -34,12 +40,15 @@ func handleGetServers(db *gorm.DB) gin.HandlerFunc {
 		if status != "" {
 			query = query.Where("status = ?", status)
 		}
+		if release != "" {
+			query = query.Where("release = ?", release)
+		}
 
 		var servers []models.Server
 		var total int64
 
 		query.Count(&total)
-		query.Offset(offset).Limit(limit).Find(&servers)
+		query.Preload("Endpoints").Offset(offset).Limit(limit).Find(&servers)
 
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
@@ -73,7 +82,7 @@ func handleGetServer(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		var server models.Server
-		if err := db.First(&server, serverID).Error; err != nil {
+		if err := db.Preload("Endpoints").First(&server, serverID).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{
 					"success": false,
@@ -103,11 +112,17 @@ func handleGetServer(db *gorm.DB) gin.HandlerFunc {
 }
 
 // handleDetectServer 检测服务器类型和状态
-func handleDetectServer() gin.HandlerFunc {
+func handleDetectServer(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req struct {
-			Address string `json:"address" binding:"required"`
-			Port    int    `json:"port"`
+			Address           string `json:"address" binding:"required"`
+			Port              int    `json:"port"`
+			Type              string `json:"type"` // 指定探测器，默认自动检测
+			HandshakeHost     string `json:"handshake_host"`
+			HandshakeProtocol int    `json:"handshake_protocol"`
+			ProxyProtocol     string `json:"proxy_protocol"`
+			LoginCheck        bool   `json:"login_check"` // 同时进行登录握手检查
+			LoginUsername     string `json:"login_username"`
 		}
 
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -122,17 +137,45 @@ func handleDetectServer() gin.HandlerFunc {
 			return
 		}
 
+		if req.Type == "" {
+			req.Type = "auto"
+		}
+		prober, ok := services.GetProber(req.Type)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": map[string]interface{}{
+					"code":    "VALIDATION_ERROR",
+					"message": "不支持的服务器类型",
+					"details": services.ProberNames(),
+				},
+			})
+			return
+		}
 		if req.Port == 0 {
-			req.Port = 25565
+			req.Port = prober.DefaultPort()
+		}
+		if !services.IsValidProxyProtocol(req.ProxyProtocol) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "无效的PROXY协议版本"}})
+			return
+		}
+		if !services.IsValidLoginUsername(req.LoginUsername) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "无效的登录检查玩家名"}})
+			return
 		}
 
-		// 执行自动检测
-		serverInfo, detectedType, err := services.AutoDetectServer(
-			req.Address,
-			req.Port,
-			19132,
-		)
+		// 执行检测，客户端断开连接时同时取消探测
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.PingTimeout)
+		defer cancel()
 
+		target := services.ProbeTarget{
+			Host:              req.Address,
+			Port:              req.Port,
+			HandshakeHost:     req.HandshakeHost,
+			HandshakeProtocol: req.HandshakeProtocol,
+			ProxyProtocol:     req.ProxyProtocol,
+		}
+		serverInfo, err := prober.Probe(ctx, target)
 		if err != nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{
 				"success": false,
@@ -145,18 +188,38 @@ func handleDetectServer() gin.HandlerFunc {
 			return
 		}
 
+		detectedType := req.Type
+		if detectedType == "auto" {
+			detectedType = serverInfo.ServerType.String()
+		}
+
+		// 登录检查失败不影响检测结果，错误信息随结果返回
+		var join interface{}
+		if req.LoginCheck && serverInfo.ServerType == services.JavaEdition {
+			result, err := services.LoginCheck(ctx, target, serverInfo.Version.Protocol, req.LoginUsername)
+			if err != nil {
+				join = gin.H{"error": err.Error()}
+			} else {
+				join = gin.H{"status": result.Status, "stage": result.Stage, "reason": result.Reason.PlainText()}
+			}
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
 			"data": map[string]interface{}{
+				"join":           join,
 				"type":           detectedType,
 				"server_type":    serverInfo.ServerType.String(),
 				"version":        serverInfo.Version.Name,
 				"protocol":       serverInfo.Version.Protocol,
+				"release":        serverInfo.Release,
 				"online":         true,
 				"players_online": serverInfo.Players.Online,
 				"max_players":    serverInfo.Players.Max,
 				"ping":           serverInfo.Ping,
 				"motd":           getDescriptionText(serverInfo.Description),
+				"motd_component": serverInfo.Description,
+				"motd_html":      serverInfo.Description.HTML(),
 				"players_sample": serverInfo.Players.Sample,
 				"favicon":        serverInfo.Favicon,
 			},
@@ -164,6 +227,16 @@ func handleDetectServer() gin.HandlerFunc {
 	}
 }
 
+// handleGetServerTypes 列出所有可用的服务器类型 (已注册的探测器)
+func handleGetServerTypes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    services.ProberInfos(),
+		})
+	}
+}
+
 // handleGetPlayers 获取玩家列表
 func handleGetPlayers(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -336,22 +409,228 @@ func handleGetServerOnlinePlayers(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
+// handleGetServerMods 获取服务器的模组列表及模组变化记录
+func handleGetServerMods(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		server, ok := loadServerByParam(c, db)
+		if !ok {
+			return
+		}
+
+		var modInfo services.ModInfo
+		if len(server.Mods) > 0 {
+			if err := json.Unmarshal(server.Mods, &modInfo); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"error": gin.H{
+						"code":    "INTERNAL_ERROR",
+						"message": "解析模组数据失败",
+					},
+				})
+				return
+			}
+		}
+
+		// 最近的模组变化记录
+		var events []models.ServerEvent
+		db.Where("server_id = ? AND event_type = ?", server.ID, "mods_changed").
+			Order("timestamp DESC").
+			Limit(20).
+			Find(&events)
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"loader":                server.ModLoader,
+				"modded":                server.ModLoader != "",
+				"mods":                  modInfo.Mods,
+				"channels":              modInfo.Channels,
+				"truncated":             modInfo.Truncated,
+				"fml_network_version":   modInfo.FMLNetworkVersion,
+				"enforces_secure_chat":  server.EnforcesSecureChat,
+				"prevents_chat_reports": server.PreventsChatReports,
+				"changes":               events,
+			},
+		})
+	}
+}
+
+// maintenanceHorizon 公开接口返回的即将到来的维护时间段范围
+const maintenanceHorizon = 7 * 24 * time.Hour
+
+// handleGetServerMaintenance 获取服务器的维护窗口、当前生效的维护及7天内即将开始的维护
+func handleGetServerMaintenance(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		server, ok := loadServerByParam(c, db)
+		if !ok {
+			return
+		}
+
+		var windows []models.MaintenanceWindow
+		db.Where("server_id = ?", server.ID).Order("id").Find(&windows)
+
+		now := time.Now()
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"paused":   server.Paused,
+				"active":   maintenance.Active(windows, now),
+				"upcoming": maintenance.Upcoming(windows, now, maintenanceHorizon, 10),
+				"windows":  windows,
+			},
+		})
+	}
+}
+
+// handleGetServerFavicon 获取服务器当前图标
+func handleGetServerFavicon(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		server, ok := loadServerByParam(c, db)
+		if !ok {
+			return
+		}
+
+		if server.FaviconHash == "" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "NOT_FOUND",
+					"message": "服务器没有图标",
+				},
+			})
+			return
+		}
+
+		// 携带与当前哈希一致的版本参数时内容不会再变化，可长期缓存
+		cacheControl := "public, max-age=300, must-revalidate"
+		if c.Query("v") == server.FaviconHash {
+			cacheControl = "public, max-age=31536000, immutable"
+		}
+		serveFavicon(c, db, server.FaviconHash, cacheControl)
+	}
+}
+
+// handleGetFaviconByHash 按哈希获取图标 (内容不可变)
+func handleGetFaviconByHash(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hash := strings.TrimSuffix(c.Param("hash"), ".png")
+		serveFavicon(c, db, hash, "public, max-age=31536000, immutable")
+	}
+}
+
+// handleGetServerFaviconHistory 获取服务器图标变更历史
+func handleGetServerFaviconHistory(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		server, ok := loadServerByParam(c, db)
+		if !ok {
+			return
+		}
+
+		var changes []models.FaviconChange
+		if err := db.Where("server_id = ?", server.ID).Order("changed_at DESC").Find(&changes).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "查询图标历史失败",
+				},
+			})
+			return
+		}
+
+		var result []map[string]interface{}
+		for _, change := range changes {
+			result = append(result, map[string]interface{}{
+				"id":         change.ID,
+				"hash":       change.Hash,
+				"changed_at": change.ChangedAt,
+				"current":    change.Hash == server.FaviconHash,
+				"url":        "/api/favicons/" + change.Hash + ".png",
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    result,
+		})
+	}
+}
+
 // -------------------------
 // Helper Functions
 // -------------------------
 
-// getDescriptionText 从Description结构体中提取文本
-func getDescriptionText(desc services.Description) string {
-	if desc.Text != "" {
-		return desc.Text
+// serveFavicon 输出指定哈希的图标，支持ETag条件请求
+func serveFavicon(c *gin.Context, db *gorm.DB, hash, cacheControl string) {
+	etag := `"` + hash + `"`
+	if c.GetHeader("If-None-Match") == etag {
+		c.Header("ETag", etag)
+		c.Header("Cache-Control", cacheControl)
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	var favicon models.Favicon
+	if err := db.Where("hash = ?", hash).First(&favicon).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "图标不存在",
+			},
+		})
+		return
 	}
-	// 如果Text为空，尝试从Extra中提取
-	if len(desc.Extra) > 0 {
-		var result string
-		for _, extra := range desc.Extra {
-			result += extra.Text
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", cacheControl)
+	c.Header("Last-Modified", favicon.CreatedAt.UTC().Format(http.TimeFormat))
+	c.Data(http.StatusOK, "image/png", favicon.Data)
+}
+
+// loadServerByParam 根据路由参数中的ID加载服务器，失败时直接写入错误响应
+func loadServerByParam(c *gin.Context, db *gorm.DB) (*models.Server, bool) {
+	serverID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_ID",
+				"message": "服务器ID格式无效",
+			},
+		})
+		return nil, false
+	}
+
+	var server models.Server
+	if err := db.First(&server, serverID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "NOT_FOUND",
+					"message": "服务器不存在",
+				},
+			})
+			return nil, false
 		}
-		return result
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "数据库查询失败",
+			},
+		})
+		return nil, false
+	}
+
+	return &server, true
+}
+
+// getDescriptionText 从MOTD聊天组件中提取纯文本
+func getDescriptionText(desc services.ChatComponent) string {
+	if text := desc.PlainText(); text != "" {
+		return text
 	}
 	return "Minecraft Server"
 }
@@ -473,10 +752,10 @@ func handleStatsOverview(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var totalServers, onlineServers int64
 		db.Model(&models.Server{}).Count(&totalServers)
-		db.Model(&models.Server{}).Where("status = ?", "online").Count(&onlineServers)
+		db.Model(&models.Server{}).Where("status IN ?", []string{"online", "degraded"}).Count(&onlineServers)
 
 		var totalPlayers, peakPlayers int64
-		db.Model(&models.Server{}).Where("status = ?", "online").Select("SUM(players_online)").Scan(&totalPlayers)
+		db.Model(&models.Server{}).Where("status IN ?", []string{"online", "degraded"}).Select("SUM(players_online)").Scan(&totalPlayers)
 
 		// 获取今日峰值
 		today := time.Now().Truncate(24 * time.Hour)
@@ -532,6 +811,9 @@ func handleServerStats(db *gorm.DB) gin.HandlerFunc {
 
 		var allStats []models.ServerStat
 		query := db.Where("server_id = ? AND timestamp >= ?", serverID, since)
+		if release := c.Query("release"); release != "" {
+			query = query.Where("release = ?", release)
+		}
 		if err := query.Order("timestamp ASC").Find(&allStats).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "查询统计数据失败"}})
 			return
@@ -540,12 +822,138 @@ func handleServerStats(db *gorm.DB) gin.HandlerFunc {
 		var stats = sampleStats(allStats, limit)
 		summary := calculateStatsSummary(stats)
 
+		// 时间范围内服务器运行过的各个版本及各类离线原因，不受 release 筛选影响
+		// SQLite 中聚合得到的时间为字符串，因此在内存中汇总
+		var records []struct {
+			Release         string
+			FailureCategory string
+			FailureMessage  string
+			Timestamp       time.Time
+		}
+		db.Model(&models.ServerStat{}).
+			Select("release, failure_category, failure_message, timestamp").
+			Where("server_id = ? AND timestamp >= ? AND (release <> '' OR failure_category <> '')", serverID, since).
+			Order("timestamp ASC").
+			Find(&records)
+
+		releases := []releaseSpan{}
+		failures := []failureSummary{}
+		releaseIndex := make(map[string]int)
+		failureIndex := make(map[string]int)
+		for _, record := range records {
+			if record.Release != "" {
+				i, ok := releaseIndex[record.Release]
+				if !ok {
+					i = len(releases)
+					releaseIndex[record.Release] = i
+					releases = append(releases, releaseSpan{Release: record.Release, FirstSeen: record.Timestamp})
+				}
+				releases[i].LastSeen = record.Timestamp
+			}
+			if record.FailureCategory != "" {
+				i, ok := failureIndex[record.FailureCategory]
+				if !ok {
+					i = len(failures)
+					failureIndex[record.FailureCategory] = i
+					failures = append(failures, failureSummary{Category: record.FailureCategory})
+				}
+				failures[i].Count++
+				failures[i].LastSeen = record.Timestamp
+				failures[i].LastMessage = record.FailureMessage
+			}
+		}
+		sort.SliceStable(failures, func(i, j int) bool { return failures[i].Count > failures[j].Count })
+
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
 			"data": gin.H{
-				"stats":   stats,
-				"summary": summary,
-				"meta":    gin.H{"range": timeRange, "since": since, "count": len(stats), "interval": getIntervalString(timeRange)},
+				"stats":       stats,
+				"summary":     summary,
+				"releases":    releases,
+				"failures":    failures,
+				"reliability": calculateReliability(db, serverID, since, now),
+				"meta":        gin.H{"range": timeRange, "since": since, "count": len(stats), "interval": getIntervalString(timeRange)},
+			},
+		})
+	}
+}
+
+// releaseSpan 服务器运行某个版本的时间段
+type releaseSpan struct {
+	Release   string    `json:"release"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// failureSummary 某类离线原因的次数及最近一次的错误信息
+type failureSummary struct {
+	Category    string    `json:"category"`
+	Count       int       `json:"count"`
+	LastSeen    time.Time `json:"last_seen"`
+	LastMessage string    `json:"last_message"`
+}
+
+// handleVersionStats 按游戏版本分组的服务器统计
+func handleVersionStats(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeRange := c.DefaultQuery("range", "24h") // 1h, 24h, 7d, 30d
+		now := time.Now()
+
+		var since time.Time
+		switch timeRange {
+		case "1h":
+			since = now.Add(-1 * time.Hour)
+		case "7d":
+			since = now.Add(-7 * 24 * time.Hour)
+		case "30d":
+			since = now.Add(-30 * 24 * time.Hour)
+		default:
+			timeRange = "24h"
+			since = now.Add(-24 * time.Hour)
+		}
+
+		// 当前各版本的服务器数和在线玩家数
+		var current []struct {
+			Release       string `json:"release"`
+			Servers       int64  `json:"servers"`
+			OnlineServers int64  `json:"online_servers"`
+			Players       int64  `json:"players"`
+		}
+		if err := db.Model(&models.Server{}).
+			Select("release, COUNT(*) AS servers, " +
+				"SUM(CASE WHEN status IN ('online', 'degraded') THEN 1 ELSE 0 END) AS online_servers, " +
+				"SUM(CASE WHEN status IN ('online', 'degraded') THEN players_online ELSE 0 END) AS players").
+			Where("release <> ''").
+			Group("release").
+			Order("servers DESC").
+			Scan(&current).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "查询版本统计失败"}})
+			return
+		}
+
+		// 时间范围内各版本的历史数据
+		var history []struct {
+			Release     string  `json:"release"`
+			Servers     int64   `json:"servers"`
+			PeakPlayers int64   `json:"peak_players"`
+			AvgPlayers  float64 `json:"avg_players"`
+		}
+		if err := db.Model(&models.ServerStat{}).
+			Select("release, COUNT(DISTINCT server_id) AS servers, MAX(players_online) AS peak_players, AVG(players_online) AS avg_players").
+			Where("timestamp >= ? AND release <> ''", since).
+			Group("release").
+			Order("servers DESC").
+			Scan(&history).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "查询版本统计失败"}})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"current": current,
+				"history": history,
+				"meta":    gin.H{"range": timeRange, "since": since},
 			},
 		})
 	}
@@ -625,23 +1033,73 @@ func calculateStatsSummary(stats []models.ServerStat) map[string]interface{} {
 		return map[string]interface{}{"avg_players": 0, "max_players": 0, "avg_ping": 0, "uptime": 0}
 	}
 	var totalPlayers, totalPing, onlineCount, maxPlayers int
+	// 维护窗口内的检查不计入可用率
+	var uptimeChecks, uptimeOnline int
+	var dns, connect, status, pingRTT latencyAverage
 	for _, stat := range stats {
 		totalPlayers += stat.PlayersOnline
-		if stat.Ping > 0 {
+		// 失败的检查记录为-1，局域网服务器的延迟可能为0
+		if stat.Ping >= 0 {
 			totalPing += stat.Ping
 			onlineCount++
 		}
+		if !stat.Maintenance {
+			uptimeChecks++
+			if stat.Ping >= 0 {
+				uptimeOnline++
+			}
+		}
 		if stat.PlayersOnline > maxPlayers {
 			maxPlayers = stat.PlayersOnline
 		}
+		dns.add(stat.DNSTime)
+		connect.add(stat.ConnectTime)
+		status.add(stat.StatusTime)
+		pingRTT.add(stat.PingRTT)
 	}
 	avgPlayers := float64(totalPlayers) / float64(len(stats))
 	var avgPing float64
 	if onlineCount > 0 {
 		avgPing = float64(totalPing) / float64(onlineCount)
 	}
-	uptime := float64(onlineCount) / float64(len(stats)) * 100
-	return map[string]interface{}{"avg_players": avgPlayers, "max_players": maxPlayers, "avg_ping": avgPing, "uptime": uptime}
+	uptime := 100.0
+	if uptimeChecks > 0 {
+		uptime = float64(uptimeOnline) / float64(uptimeChecks) * 100
+	}
+	return map[string]interface{}{
+		"avg_players": avgPlayers,
+		"max_players": maxPlayers,
+		"avg_ping":    avgPing,
+		"uptime":      uptime,
+		"jitter":      stats[len(stats)-1].Jitter,
+		"latency": map[string]interface{}{
+			"dns":     dns.value(),
+			"connect": connect.value(),
+			"status":  status.value(),
+			"ping":    pingRTT.value(),
+		},
+	}
+}
+
+// latencyAverage 计算某一阶段耗时的平均值，忽略未测量(-1)的记录
+type latencyAverage struct {
+	total int
+	count int
+}
+
+func (a *latencyAverage) add(ms int) {
+	if ms >= 0 {
+		a.total += ms
+		a.count++
+	}
+}
+
+// value 返回平均耗时，没有任何测量值时返回-1
+func (a latencyAverage) value() float64 {
+	if a.count == 0 {
+		return -1
+	}
+	return float64(a.total) / float64(a.count)
 }
 
 // getIntervalString 获取时间间隔字符串
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"etamonitor/internal/events"
+	"etamonitor/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// =================================================================================
+// Incident Handlers (停机事件)
+//
+// 此文件包含服务器停机事件相关的API处理器。
+// 事件列表公开可见，添加备注和手动结束事件需要用户认证。
+// =================================================================================
+
+// handleGetIncidents 获取停机事件列表，可按服务器和状态 (open, resolved) 筛选
+func handleGetIncidents(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 || limit > 200 {
+			limit = 50
+		}
+
+		query := db.Preload("Server").Order("started_at DESC").Limit(limit)
+		if serverID := c.Query("server_id"); serverID != "" {
+			query = query.Where("server_id = ?", serverID)
+		}
+		switch c.Query("status") {
+		case "open":
+			query = query.Where("ended_at IS NULL")
+		case "resolved":
+			query = query.Where("ended_at IS NOT NULL")
+		case "":
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "无效的事件状态"}})
+			return
+		}
+
+		var incidents []models.Incident
+		if err := query.Find(&incidents).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "查询停机事件失败"}})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": incidents})
+	}
+}
+
+// handleUpdateIncident 为停机事件添加备注 (需要认证)
+func handleUpdateIncident(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var incident models.Incident
+		if err := db.First(&incident, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "停机事件不存在"}})
+			return
+		}
+
+		var req struct {
+			Notes string `json:"notes"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": err.Error()}})
+			return
+		}
+
+		if err := db.Model(&incident).Update("notes", req.Notes).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "更新停机事件失败"}})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": incident})
+	}
+}
+
+// handleResolveIncident 手动结束停机事件，可同时附加备注 (需要认证)
+// 监控只在服务器从可访问变为离线时开始事件，服务器仍然离线时不会重新开始，直到恢复后再次离线
+func handleResolveIncident(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var incident models.Incident
+		if err := db.Preload("Server").First(&incident, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "停机事件不存在"}})
+			return
+		}
+		if incident.EndedAt != nil {
+			c.JSON(http.StatusConflict, gin.H{"success": false, "error": map[string]interface{}{"code": "ALREADY_RESOLVED", "message": "停机事件已结束"}})
+			return
+		}
+
+		var req struct {
+			Notes *string `json:"notes"`
+		}
+		// 请求体可以为空
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": err.Error()}})
+				return
+			}
+		}
+
+		now := time.Now()
+		incident.EndedAt = &now
+		incident.Duration = int64(now.Sub(incident.StartedAt).Seconds())
+		incident.ResolvedBy = c.GetString("username")
+		columns := []string{"ended_at", "duration", "resolved_by"}
+		if req.Notes != nil {
+			incident.Notes = *req.Notes
+			columns = append(columns, "notes")
+		}
+		if err := db.Model(&incident).Select(columns).Updates(&incident).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "结束停机事件失败"}})
+			return
+		}
+		events.Publish(events.IncidentResolved, incident.ServerID, events.NewIncidentData(incident, incident.Server))
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": incident})
+	}
+}
+
+// calculateReliability 计算服务器在时间范围内的停机次数、停机时长、MTTR和MTBF (秒)
+// MTTR为已结束事件的平均持续时间，MTBF为范围内正常运行时间除以停机次数，无法计算时为null
+func calculateReliability(db *gorm.DB, serverID string, since, now time.Time) gin.H {
+	// 服务器添加之前的时间不计入
+	var server models.Server
+	if err := db.Select("id, created_at").First(&server, serverID).Error; err == nil && server.CreatedAt.After(since) {
+		since = server.CreatedAt
+	}
+
+	var incidents []models.Incident
+	db.Where("server_id = ? AND started_at < ? AND (ended_at IS NULL OR ended_at > ?)", serverID, now, since).
+		Find(&incidents)
+
+	var downtime, repairTime time.Duration
+	var resolved, open int
+	for _, incident := range incidents {
+		start, end := incident.StartedAt, now
+		if incident.EndedAt != nil {
+			end = *incident.EndedAt
+			repairTime += end.Sub(incident.StartedAt)
+			resolved++
+		} else {
+			open++
+		}
+		if start.Before(since) {
+			start = since
+		}
+		if end.After(start) {
+			downtime += end.Sub(start)
+		}
+	}
+
+	var mttr, mtbf interface{}
+	if resolved > 0 {
+		mttr = int64((repairTime / time.Duration(resolved)).Seconds())
+	}
+	if len(incidents) > 0 {
+		if uptime := now.Sub(since) - downtime; uptime > 0 {
+			mtbf = int64((uptime / time.Duration(len(incidents))).Seconds())
+		} else {
+			mtbf = int64(0)
+		}
+	}
+
+	return gin.H{
+		"incidents": len(incidents),
+		"open":      open,
+		"downtime":  int64(downtime.Seconds()),
+		"mttr":      mttr,
+		"mtbf":      mtbf,
+	}
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"net/http"
+
+	"etamonitor/internal/monitor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =================================================================================
+// Monitor Handlers (监控服务)
+//
+// 此文件包含监控服务运行状态相关的API处理器，均需要用户认证。
+// =================================================================================
+
+// handleMonitorMetrics 获取检查调度器的运行指标，包括错过和延迟的检查次数 (需要认证)
+func handleMonitorMetrics(monitorService *monitor.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": monitorService.Metrics()})
+	}
+}
@@ -7,13 +7,14 @@ import (
 
 	"etamonitor/internal/auth"
 	"etamonitor/internal/config"
+	"etamonitor/internal/monitor"
 	"etamonitor/internal/static"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
+func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config, monitorService *monitor.Service) {
 	// 中间件
 	router.Use(auth.CORSMiddleware())
 
@@ -27,7 +28,15 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	// 需要认证的路由
 	protected := api.Group("/")
 	protected.Use(auth.AuthMiddleware(cfg.JWTSecret))
-	setupProtectedRoutes(protected, db, cfg)
+	setupProtectedRoutes(protected, db, cfg, monitorService)
+
+	// 远程探测节点路由 (使用探测节点令牌认证)
+	agentRoutes := api.Group("/agent")
+	agentRoutes.Use(auth.AgentAuthMiddleware(db))
+	{
+		agentRoutes.GET("/targets", handleAgentTargets(db))
+		agentRoutes.POST("/results", handleAgentReport(db))
+	}
 
 	// WebSocket路由
 	router.GET("/ws", handleWebSocket)
@@ -85,16 +94,27 @@ func setupPublicRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
 		servers.GET("/", handleGetServers(db))
 		servers.GET("/:id", handleGetServer(db))
 		servers.GET("/:id/players", handleGetServerOnlinePlayers(db))
+		servers.GET("/:id/mods", handleGetServerMods(db))
+		servers.GET("/:id/favicon.png", handleGetServerFavicon(db))
+		servers.GET("/:id/favicons", handleGetServerFaviconHistory(db))
+		servers.GET("/:id/maintenance", handleGetServerMaintenance(db))
 	}
 
+	// 服务器图标（按哈希，内容不可变）
+	r.GET("/favicons/:hash", handleGetFaviconByHash(db))
+
 	// 统计数据
 	stats := r.Group("/stats")
 	{
 		stats.GET("/overview", handleStatsOverview(db))
 		stats.GET("/servers/:id", handleServerStats(db))
 		stats.GET("/players/:id", handlePlayerStats(db))
+		stats.GET("/versions", handleVersionStats(db))
 	}
 
+	// 停机事件
+	r.GET("/incidents", handleGetIncidents(db))
+
 	// 玩家信息
 	players := r.Group("/players")
 	{
@@ -110,7 +130,7 @@ func setupPublicRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
 	}
 }
 
-func setupProtectedRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+func setupProtectedRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config, monitorService *monitor.Service) {
 	// 认证相关
 	auth := r.Group("/auth")
 	{
@@ -122,11 +142,61 @@ func setupProtectedRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
 	// 服务器管理
 	servers := r.Group("/servers")
 	{
-		servers.POST("/", handleCreateServer(db))
-		servers.PUT("/:id", handleUpdateServer(db))
+		servers.POST("/", handleCreateServer(db, cfg.EncryptionKey, monitorService))
+		servers.PUT("/:id", handleUpdateServer(db, cfg.EncryptionKey, monitorService))
 		servers.DELETE("/:id", handleDeleteServer(db))
-		servers.POST("/:id/ping", handlePingServer(db))
-		servers.POST("/detect", handleDetectServer())
+		servers.GET("/:id/settings", handleGetServerSettings(db))
+		servers.GET("/:id/agents", handleGetServerAgentResults(db))
+		servers.POST("/:id/ping", handlePingServer(db, monitorService))
+		servers.POST("/:id/endpoints", handleCreateServerEndpoint(db, monitorService))
+		servers.PUT("/:id/endpoints/:endpointId", handleUpdateServerEndpoint(db, monitorService))
+		servers.DELETE("/:id/endpoints/:endpointId", handleDeleteServerEndpoint(db))
+		servers.POST("/:id/maintenance", handleCreateMaintenanceWindow(db))
+		servers.DELETE("/:id/maintenance/:windowId", handleDeleteMaintenanceWindow(db))
+		servers.GET("/detect", handleGetServerTypes())
+		servers.POST("/detect", handleDetectServer(cfg))
+	}
+
+	// 停机事件管理
+	incidents := r.Group("/incidents")
+	{
+		incidents.PUT("/:id", handleUpdateIncident(db))
+		incidents.POST("/:id/resolve", handleResolveIncident(db))
+	}
+
+	// 告警规则与告警
+	alerts := r.Group("/alerts")
+	{
+		alerts.GET("/", handleGetAlerts(db))
+		alerts.GET("/rules", handleGetAlertRules(db))
+		alerts.POST("/rules", handleCreateAlertRule(db))
+		alerts.PUT("/rules/:id", handleUpdateAlertRule(db))
+		alerts.DELETE("/rules/:id", handleDeleteAlertRule(db))
+	}
+
+	// 外发Webhook
+	hooks := r.Group("/webhooks")
+	{
+		hooks.GET("/", handleGetWebhooks(db))
+		hooks.POST("/", handleCreateWebhook(db, cfg.EncryptionKey))
+		hooks.PUT("/:id", handleUpdateWebhook(db, cfg.EncryptionKey))
+		hooks.DELETE("/:id", handleDeleteWebhook(db))
+		hooks.GET("/:id/deliveries", handleGetWebhookDeliveries(db))
+		hooks.POST("/:id/test", handleTestWebhook(db, cfg))
+	}
+
+	// 远程探测节点管理
+	agents := r.Group("/agents")
+	{
+		agents.GET("/", handleGetAgents(db))
+		agents.POST("/", handleCreateAgent(db))
+		agents.DELETE("/:id", handleDeleteAgent(db))
+	}
+
+	// 监控服务状态
+	monitoring := r.Group("/monitor")
+	{
+		monitoring.GET("/metrics", handleMonitorMetrics(monitorService))
 	}
 
 	// 用户管理
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"etamonitor/internal/auth"
+	"etamonitor/internal/config"
+	"etamonitor/internal/models"
+	"etamonitor/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// =================================================================================
+// Webhook Handlers (外发通知)
+//
+// 此文件包含Webhook管理和投递记录查询相关的API处理器，均需要用户认证。
+// 签名密钥加密保存，只在创建或更换时返回一次。
+// =================================================================================
+
+// webhookRequest 创建或更新Webhook的请求
+type webhookRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	URL       string   `json:"url" binding:"required"`
+	Type      string   `json:"type"` // generic (默认) 或 discord
+	Events    []string `json:"events"`
+	ServerIDs []uint   `json:"server_ids"`
+	Enabled   *bool    `json:"enabled"`       // 默认启用
+	Secret    string   `json:"secret"`        // 为空时创建会自动生成
+	Rotate    bool     `json:"rotate_secret"` // 更新时重新生成签名密钥
+}
+
+// handleGetWebhooks 获取Webhook列表 (需要认证)
+func handleGetWebhooks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var hooks []models.Webhook
+		db.Order("id").Find(&hooks)
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": hooks})
+	}
+}
+
+// handleCreateWebhook 创建Webhook，签名密钥只在创建时返回一次 (需要认证)
+func handleCreateWebhook(db *gorm.DB, encryptionKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req webhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": err.Error()}})
+			return
+		}
+
+		secret := req.Secret
+		if secret == "" {
+			generated, err := webhooks.GenerateSecret()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "INTERNAL_ERROR", "message": err.Error()}})
+				return
+			}
+			secret = generated
+		}
+
+		var hook models.Webhook
+		if !applyWebhookRequest(c, db, encryptionKey, &hook, &req, secret) {
+			return
+		}
+		if err := db.Create(&hook).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "创建Webhook失败"}})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"success": true,
+			"data": gin.H{
+				"webhook": hook,
+				"secret":  secret,
+			},
+		})
+	}
+}
+
+// handleUpdateWebhook 更新Webhook，提供 secret 或设置 rotate_secret 时更换签名密钥 (需要认证)
+func handleUpdateWebhook(db *gorm.DB, encryptionKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var hook models.Webhook
+		if err := db.First(&hook, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "Webhook不存在"}})
+			return
+		}
+
+		var req webhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": err.Error()}})
+			return
+		}
+
+		secret := req.Secret
+		if secret == "" && req.Rotate {
+			generated, err := webhooks.GenerateSecret()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "INTERNAL_ERROR", "message": err.Error()}})
+				return
+			}
+			secret = generated
+		}
+
+		if !applyWebhookRequest(c, db, encryptionKey, &hook, &req, secret) {
+			return
+		}
+		if err := db.Save(&hook).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "更新Webhook失败"}})
+			return
+		}
+
+		data := gin.H{"webhook": hook}
+		if secret != "" {
+			data["secret"] = secret
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+	}
+}
+
+// applyWebhookRequest 将请求写入 hook 并校验，secret 不为空时加密保存为新的签名密钥
+// 失败时返回错误响应并返回false
+func applyWebhookRequest(c *gin.Context, db *gorm.DB, encryptionKey string, hook *models.Webhook, req *webhookRequest, secret string) bool {
+	hook.Name = req.Name
+	hook.URL = req.URL
+	hook.Type = req.Type
+	hook.Events = req.Events
+	hook.ServerIDs = req.ServerIDs
+	hook.Enabled = req.Enabled == nil || *req.Enabled
+
+	if err := webhooks.Validate(hook); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": err.Error()}})
+		return false
+	}
+	if len(hook.ServerIDs) > 0 {
+		var count int64
+		db.Model(&models.Server{}).Where("id IN ?", hook.ServerIDs).Count(&count)
+		if int(count) != len(hook.ServerIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "服务器不存在"}})
+			return false
+		}
+	}
+
+	if secret != "" {
+		encrypted, err := auth.EncryptSecret(secret, encryptionKey)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "INTERNAL_ERROR", "message": "加密签名密钥失败"}})
+			return false
+		}
+		hook.Secret = encrypted
+	}
+	return true
+}
+
+// handleDeleteWebhook 删除Webhook及其投递记录 (需要认证)
+func handleDeleteWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		result := db.Delete(&models.Webhook{}, id)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "删除Webhook失败"}})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "Webhook不存在"}})
+			return
+		}
+		db.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{})
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Webhook删除成功"})
+	}
+}
+
+// handleGetWebhookDeliveries 获取Webhook的投递记录，可按状态筛选 (需要认证)
+func handleGetWebhookDeliveries(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 || limit > 200 {
+			limit = 50
+		}
+
+		query := db.Where("webhook_id = ?", c.Param("id")).Order("id DESC").Limit(limit)
+		switch status := c.Query("status"); status {
+		case webhooks.StatusPending, webhooks.StatusSucceeded, webhooks.StatusFailed:
+			query = query.Where("status = ?", status)
+		case "":
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": map[string]interface{}{"code": "VALIDATION_ERROR", "message": "无效的投递状态"}})
+			return
+		}
+
+		var deliveries []models.WebhookDelivery
+		if err := query.Find(&deliveries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "DATABASE_ERROR", "message": "查询投递记录失败"}})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": deliveries})
+	}
+}
+
+// handleTestWebhook 向Webhook发送一次测试事件并返回投递结果 (需要认证)
+func handleTestWebhook(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var hook models.Webhook
+		if err := db.First(&hook, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": map[string]interface{}{"code": "NOT_FOUND", "message": "Webhook不存在"}})
+			return
+		}
+
+		delivery, err := webhooks.SendTest(c.Request.Context(), db, cfg.EncryptionKey, cfg.PublicURL, &hook)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": map[string]interface{}{"code": "INTERNAL_ERROR", "message": err.Error()}})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": delivery})
+	}
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"etamonitor/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GenerateAgentToken 生成探测节点的访问令牌
+func GenerateAgentToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("生成令牌失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// HashAgentToken 计算令牌的SHA-256摘要，数据库中只保存摘要
+func HashAgentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AgentAuthMiddleware 校验探测节点的访问令牌 (Authorization: Bearer <token>)
+func AgentAuthMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" || token == c.GetHeader("Authorization") {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error": map[string]interface{}{
+					"code":    "UNAUTHORIZED",
+					"message": "Bearer token required",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		var agent models.Agent
+		if err := db.Where("token_hash = ?", HashAgentToken(token)).First(&agent).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error": map[string]interface{}{
+					"code":    "UNAUTHORIZED",
+					"message": "Invalid agent token",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		now := time.Now()
+		db.Model(&agent).Update("last_seen", &now)
+
+		c.Set("agent_id", agent.ID)
+		c.Set("agent_name", agent.Name)
+		c.Next()
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,16 +21,23 @@ type Config struct {
 	Host        string `json:"host"`
 	Port        string `json:"port"`
 	Environment string `json:"environment"`
+	PublicURL   string `json:"public_url"` // 外部访问地址，用于通知中的链接和图片
 
 	// JWT认证配置
 	JWTSecret    string        `json:"jwt_secret"`
 	JWTExpiresIn time.Duration `json:"jwt_expires_in"`
 
+	// 安全配置
+	EncryptionKey string `json:"encryption_key"` // 用于加密RCON密码等敏感字段
+
 	// 监控配置
 	MonitorInterval        time.Duration `json:"monitor_interval"`
 	PingTimeout            time.Duration `json:"ping_timeout"`
 	MaxConcurrent          int           `json:"max_concurrent"`
 	ActivityRetentionTime  time.Duration `json:"activity_retention_time"` // 活动记录保留时间
+	AgentQuorum            int           `json:"agent_quorum"`            // 判定离线所需的探测点数量，0表示多数
+	FailureThreshold       int           `json:"failure_threshold"`       // 在线服务器连续失败多少次才判定离线
+	ConfirmInterval        time.Duration `json:"confirm_interval"`        // 确认离线期间的重试间隔
 
 	// 日志配置
 	LogLevel  string `json:"log_level"`
@@ -45,6 +54,7 @@ type ConfigFile struct {
 		Host        string `json:"host"`
 		Port        string `json:"port"`
 		Environment string `json:"environment"`
+		PublicURL   string `json:"public_url"`
 	} `json:"server"`
 
 	Database struct {
@@ -56,11 +66,18 @@ type ConfigFile struct {
 		ExpiresIn string `json:"expires_in"`
 	} `json:"jwt"`
 
+	Security struct {
+		EncryptionKey string `json:"encryption_key"`
+	} `json:"security"`
+
 	Monitor struct {
 		Interval              string `json:"interval"`
 		PingTimeout           string `json:"ping_timeout"`
 		MaxConcurrent         int    `json:"max_concurrent"`
 		ActivityRetentionTime string `json:"activity_retention_time"`
+		AgentQuorum           int    `json:"agent_quorum"`
+		FailureThreshold      int    `json:"failure_threshold"`
+		ConfirmInterval       string `json:"confirm_interval"`
 	} `json:"monitor"`
 
 	Logging struct {
@@ -111,6 +128,8 @@ func setDefaults(config *Config) {
 	config.PingTimeout = 10 * time.Second
 	config.MaxConcurrent = 10
 	config.ActivityRetentionTime = 15 * time.Minute
+	config.FailureThreshold = 3
+	config.ConfirmInterval = 5 * time.Second
 	config.LogLevel = "info"
 	config.LogFormat = "json"
 	config.AllowOrigins = []string{"*"}
@@ -155,6 +174,9 @@ func applyConfigFile(config *Config, configFile *ConfigFile) {
 	if configFile.Server.Environment != "" {
 		config.Environment = configFile.Server.Environment
 	}
+	if configFile.Server.PublicURL != "" {
+		config.PublicURL = configFile.Server.PublicURL
+	}
 
 	if configFile.Database.Path != "" {
 		config.DatabasePath = configFile.Database.Path
@@ -169,6 +191,10 @@ func applyConfigFile(config *Config, configFile *ConfigFile) {
 		}
 	}
 
+	if configFile.Security.EncryptionKey != "" {
+		config.EncryptionKey = configFile.Security.EncryptionKey
+	}
+
 	if configFile.Monitor.Interval != "" {
 		if duration, err := time.ParseDuration(configFile.Monitor.Interval); err == nil {
 			config.MonitorInterval = duration
@@ -187,6 +213,17 @@ func applyConfigFile(config *Config, configFile *ConfigFile) {
 			config.ActivityRetentionTime = duration
 		}
 	}
+	if configFile.Monitor.AgentQuorum > 0 {
+		config.AgentQuorum = configFile.Monitor.AgentQuorum
+	}
+	if configFile.Monitor.FailureThreshold > 0 {
+		config.FailureThreshold = configFile.Monitor.FailureThreshold
+	}
+	if configFile.Monitor.ConfirmInterval != "" {
+		if duration, err := time.ParseDuration(configFile.Monitor.ConfirmInterval); err == nil {
+			config.ConfirmInterval = duration
+		}
+	}
 
 	if configFile.Logging.Level != "" {
 		config.LogLevel = configFile.Logging.Level
@@ -207,7 +244,9 @@ func loadEnvironmentVariables(config *Config) {
 	config.Host = getEnv("HOST", config.Host)
 	config.Port = getEnv("PORT", config.Port)
 	config.Environment = getEnv("GIN_MODE", config.Environment)
+	config.PublicURL = getEnv("PUBLIC_URL", config.PublicURL)
 	config.JWTSecret = getEnv("JWT_SECRET", config.JWTSecret)
+	config.EncryptionKey = getEnv("ENCRYPTION_KEY", config.EncryptionKey)
 	config.LogLevel = getEnv("LOG_LEVEL", config.LogLevel)
 	config.LogFormat = getEnv("LOG_FORMAT", config.LogFormat)
 
@@ -230,6 +269,14 @@ func loadEnvironmentVariables(config *Config) {
 	}
 
 	config.MaxConcurrent = getEnvInt("MAX_CONCURRENT", config.MaxConcurrent)
+	config.AgentQuorum = getEnvInt("AGENT_QUORUM", config.AgentQuorum)
+	config.FailureThreshold = getEnvInt("FAILURE_THRESHOLD", config.FailureThreshold)
+
+	if confirmInterval := os.Getenv("CONFIRM_INTERVAL"); confirmInterval != "" {
+		if duration, err := time.ParseDuration(confirmInterval); err == nil {
+			config.ConfirmInterval = duration
+		}
+	}
 	
 	if activityRetention := os.Getenv("ACTIVITY_RETENTION_TIME"); activityRetention != "" {
 		if duration, err := time.ParseDuration(activityRetention); err == nil {
@@ -256,13 +303,18 @@ func saveConfigFile(configPath string, config *Config) error {
 	configFile.Server.Host = config.Host
 	configFile.Server.Port = config.Port
 	configFile.Server.Environment = config.Environment
+	configFile.Server.PublicURL = config.PublicURL
 	configFile.Database.Path = config.DatabasePath
 	configFile.JWT.Secret = config.JWTSecret
 	configFile.JWT.ExpiresIn = config.JWTExpiresIn.String()
+	configFile.Security.EncryptionKey = config.EncryptionKey
 	configFile.Monitor.Interval = config.MonitorInterval.String()
 	configFile.Monitor.PingTimeout = config.PingTimeout.String()
 	configFile.Monitor.MaxConcurrent = config.MaxConcurrent
 	configFile.Monitor.ActivityRetentionTime = config.ActivityRetentionTime.String()
+	configFile.Monitor.AgentQuorum = config.AgentQuorum
+	configFile.Monitor.FailureThreshold = config.FailureThreshold
+	configFile.Monitor.ConfirmInterval = config.ConfirmInterval.String()
 	configFile.Logging.Level = config.LogLevel
 	configFile.Logging.Format = config.LogFormat
 	configFile.CORS.AllowOrigins = config.AllowOrigins
@@ -300,6 +352,29 @@ func validateConfig(config *Config) {
 		}
 	}
 
+	// 检查加密密钥，未配置时自动生成，密钥丢失将导致已加密的字段无法解密
+	if config.EncryptionKey == "" {
+		if key, err := generateRandomSecret(); err == nil {
+			config.EncryptionKey = key
+			if err := saveConfigFile("./config.json", config); err == nil {
+				log.Println("已生成并保存新的加密密钥")
+			} else {
+				log.Printf("警告: 无法保存新生成的加密密钥: %v", err)
+			}
+		} else {
+			log.Printf("警告: 无法生成随机加密密钥: %v", err)
+		}
+	}
+
+	// 外部访问地址需包含协议，去掉末尾的斜杠便于拼接路径
+	if config.PublicURL != "" {
+		if u, err := url.Parse(config.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			log.Printf("警告: 无效的外部访问地址 %s，已忽略", config.PublicURL)
+			config.PublicURL = ""
+		}
+		config.PublicURL = strings.TrimRight(config.PublicURL, "/")
+	}
+
 	if config.MonitorInterval < 5*time.Second {
 		log.Println("警告: 监控间隔过短，设置为5秒")
 		config.MonitorInterval = 5 * time.Second
@@ -309,6 +384,21 @@ func validateConfig(config *Config) {
 		log.Println("警告: Ping超时时间过长，设置为30秒")
 		config.PingTimeout = 30 * time.Second
 	}
+
+	if config.MaxConcurrent < 1 {
+		log.Println("警告: 最大并发数无效，设置为10")
+		config.MaxConcurrent = 10
+	}
+
+	if config.FailureThreshold < 1 {
+		log.Println("警告: 离线判定失败次数无效，设置为1")
+		config.FailureThreshold = 1
+	}
+
+	if config.ConfirmInterval < time.Second {
+		log.Println("警告: 离线确认重试间隔过短，设置为1秒")
+		config.ConfirmInterval = time.Second
+	}
 }
 
 // printConfig 打印配置信息（隐藏敏感信息）
@@ -316,11 +406,20 @@ func printConfig(config *Config) {
 	fmt.Println("=== etaMonitor 配置信息 ===")
 	fmt.Printf("监听地址: %s:%s\n", config.Host, config.Port)
 	fmt.Printf("运行模式: %s\n", config.Environment)
+	if config.PublicURL != "" {
+		fmt.Printf("外部访问地址: %s\n", config.PublicURL)
+	}
 	fmt.Printf("数据库路径: %s\n", config.DatabasePath)
 	fmt.Printf("监控间隔: %v\n", config.MonitorInterval)
 	fmt.Printf("Ping超时: %v\n", config.PingTimeout)
 	fmt.Printf("最大并发: %d\n", config.MaxConcurrent)
 	fmt.Printf("活动记录保留时间: %v\n", config.ActivityRetentionTime)
+	if config.AgentQuorum > 0 {
+		fmt.Printf("离线判定探测点数: %d\n", config.AgentQuorum)
+	} else {
+		fmt.Println("离线判定探测点数: 多数")
+	}
+	fmt.Printf("离线判定失败次数: %d (重试间隔 %v)\n", config.FailureThreshold, config.ConfirmInterval)
 	fmt.Printf("日志级别: %s\n", config.LogLevel)
 	fmt.Printf("JWT过期时间: %v\n", config.JWTExpiresIn)
 	fmt.Println("========================")
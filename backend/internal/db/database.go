@@ -28,20 +28,48 @@ func Init(dbPath string) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	// 延迟分段字段是后来添加的，迁移前的记录没有测量过，需要标记为未测量(-1)
+	backfillLatency := db.Migrator().HasTable(&models.ServerStat{}) && !db.Migrator().HasColumn(&models.ServerStat{}, "DNSTime")
+
+	// 玩家UUID原为全表唯一索引，多个UUID未知的玩家会冲突，改为只约束非空UUID的部分索引
+	if db.Migrator().HasIndex(&models.Player{}, "idx_players_uuid") {
+		if err := db.Migrator().DropIndex(&models.Player{}, "idx_players_uuid"); err != nil {
+			return nil, fmt.Errorf("failed to drop players uuid index: %w", err)
+		}
+	}
+
 	// 自动迁移数据库表
 	err = db.AutoMigrate(
 		&models.Server{},
 		&models.ServerStat{},
+		&models.ServerEndpoint{},
+		&models.ServerEvent{},
+		&models.MaintenanceWindow{},
+		&models.Incident{},
+		&models.AlertRule{},
+		&models.Alert{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.Favicon{},
+		&models.FaviconChange{},
 		&models.Player{},
 		&models.PlayerSession{},
 		&models.PlayerActivity{},
 		&models.PlayerTitle{},
 		&models.User{},
+		&models.Agent{},
+		&models.AgentResult{},
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if backfillLatency {
+		if err := db.Exec("UPDATE server_stats SET dns_time = -1, connect_time = -1, status_time = -1, ping_rtt = -1").Error; err != nil {
+			return nil, fmt.Errorf("failed to backfill latency columns: %w", err)
+		}
+	}
+
 	// 创建默认管理员用户
 	if err := createDefaultAdmin(db); err != nil {
 		log.Printf("Error: Failed to create admin user: %v", err)
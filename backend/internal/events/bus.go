@@ -0,0 +1,94 @@
+package events
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 事件类型
+const (
+	ServerStatusChanged = "server.status_changed"
+	PlayerJoin          = "player.join"
+	PlayerLeave         = "player.leave"
+	PlayerTitleAwarded  = "player.title_awarded"
+	AlertFiring         = "alert.firing"
+	AlertResolved       = "alert.resolved"
+	IncidentOpened      = "incident.opened"
+	IncidentResolved    = "incident.resolved"
+)
+
+// Types 所有可订阅的事件类型
+var Types = []string{
+	ServerStatusChanged,
+	PlayerJoin,
+	PlayerLeave,
+	PlayerTitleAwarded,
+	AlertFiring,
+	AlertResolved,
+	IncidentOpened,
+	IncidentResolved,
+}
+
+// Event 监控过程中产生的事件
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	ServerID  uint        `json:"server_id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// Handler 事件处理函数，在发布事件的goroutine中调用，不能阻塞
+type Handler func(Event)
+
+var (
+	mu       sync.RWMutex
+	handlers []Handler
+)
+
+// Subscribe 订阅所有事件
+func Subscribe(handler Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handlers = append(handlers, handler)
+}
+
+// New 创建带有唯一ID和当前时间的事件
+func New(eventType string, serverID uint, data interface{}) Event {
+	return Event{
+		ID:        newEventID(),
+		Type:      eventType,
+		ServerID:  serverID,
+		Timestamp: time.Now(),
+		Data:      data,
+	}
+}
+
+// Publish 发布事件给所有订阅者
+func Publish(eventType string, serverID uint, data interface{}) {
+	event := New(eventType, serverID, data)
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// IsValidType 判断是否为已知的事件类型
+func IsValidType(eventType string) bool {
+	for _, t := range Types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func newEventID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return fmt.Sprintf("evt_%x", b)
+}
@@ -0,0 +1,32 @@
+package events
+
+import "etamonitor/internal/models"
+
+// ServerRef 事件中携带的服务器信息，只包含对外公开的字段
+// 事件在其他goroutine中序列化，不能直接引用监控中正在更新的服务器
+type ServerRef struct {
+	ID      uint   `json:"id"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// NewServerRef 复制服务器的公开字段
+func NewServerRef(server *models.Server) ServerRef {
+	return ServerRef{ID: server.ID, Name: server.Name, Address: server.Address}
+}
+
+// IncidentData 停机事件开始和结束时发布的事件数据
+type IncidentData struct {
+	models.Incident
+	Server ServerRef `json:"server"`
+}
+
+// NewIncidentData 复制停机事件及所属服务器的公开字段，server 为空时只包含服务器ID
+func NewIncidentData(incident models.Incident, server *models.Server) IncidentData {
+	data := IncidentData{Incident: incident, Server: ServerRef{ID: incident.ServerID}}
+	if server != nil {
+		data.Server = NewServerRef(server)
+	}
+	data.Incident.Server = nil
+	return data
+}
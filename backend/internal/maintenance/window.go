@@ -0,0 +1,131 @@
+package maintenance
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"etamonitor/internal/models"
+)
+
+// MaxDuration 周期性维护窗口的最长持续时间
+const MaxDuration = 7 * 24 * time.Hour
+
+// Occurrence 维护窗口的一次具体时间段
+type Occurrence struct {
+	WindowID  uint      `json:"window_id"`
+	Title     string    `json:"title"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Recurring bool      `json:"recurring"`
+}
+
+// Validate 校验维护窗口的配置
+// 一次性窗口需要开始和结束时间，周期性窗口需要cron表达式和持续时间
+func Validate(window *models.MaintenanceWindow) error {
+	if window.Cron == "" {
+		if window.StartsAt == nil || window.EndsAt == nil {
+			return fmt.Errorf("一次性维护窗口需要开始和结束时间")
+		}
+		if !window.EndsAt.After(*window.StartsAt) {
+			return fmt.Errorf("结束时间必须晚于开始时间")
+		}
+		return nil
+	}
+
+	if _, err := ParseCron(window.Cron); err != nil {
+		return err
+	}
+	duration := time.Duration(window.Duration) * time.Minute
+	if duration <= 0 || duration > MaxDuration {
+		return fmt.Errorf("周期性维护窗口的持续时间需在1到%d分钟之间", int(MaxDuration.Minutes()))
+	}
+	if _, err := location(window.Timezone); err != nil {
+		return fmt.Errorf("无效的时区: %s", window.Timezone)
+	}
+	return nil
+}
+
+// Active 返回在 now 时刻生效的维护窗口，没有时返回nil
+func Active(windows []models.MaintenanceWindow, now time.Time) *Occurrence {
+	for i := range windows {
+		if occurrence, ok := current(&windows[i], now); ok && !occurrence.StartsAt.After(now) {
+			return &occurrence
+		}
+	}
+	return nil
+}
+
+// Upcoming 返回 now 之后 horizon 时间内生效或开始的维护时间段，按开始时间排序
+// 每个周期性窗口最多返回 limit 次
+func Upcoming(windows []models.MaintenanceWindow, now time.Time, horizon time.Duration, limit int) []Occurrence {
+	end := now.Add(horizon)
+	occurrences := []Occurrence{}
+
+	for i := range windows {
+		window := &windows[i]
+		occurrence, ok := current(window, now)
+		for n := 0; ok && n < limit && occurrence.StartsAt.Before(end); n++ {
+			occurrences = append(occurrences, occurrence)
+			if !occurrence.Recurring {
+				break
+			}
+			occurrence, ok = next(window, occurrence.StartsAt)
+		}
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].StartsAt.Before(occurrences[j].StartsAt)
+	})
+	return occurrences
+}
+
+// current 返回窗口在 now 时刻正在生效或之后最早开始的时间段
+func current(window *models.MaintenanceWindow, now time.Time) (Occurrence, bool) {
+	if window.Cron == "" {
+		if window.StartsAt == nil || window.EndsAt == nil || !window.EndsAt.After(now) {
+			return Occurrence{}, false
+		}
+		return Occurrence{
+			WindowID: window.ID,
+			Title:    window.Title,
+			StartsAt: *window.StartsAt,
+			EndsAt:   *window.EndsAt,
+		}, true
+	}
+
+	// 在 now 之前一个持续时间内开始的时间段仍在生效
+	return next(window, now.Add(-time.Duration(window.Duration)*time.Minute))
+}
+
+// next 返回周期性窗口在 after 之后开始的第一个时间段
+func next(window *models.MaintenanceWindow, after time.Time) (Occurrence, bool) {
+	schedule, err := ParseCron(window.Cron)
+	if err != nil {
+		return Occurrence{}, false
+	}
+	loc, err := location(window.Timezone)
+	if err != nil {
+		return Occurrence{}, false
+	}
+
+	start := schedule.Next(after.In(loc))
+	if start.IsZero() {
+		return Occurrence{}, false
+	}
+	return Occurrence{
+		WindowID:  window.ID,
+		Title:     window.Title,
+		StartsAt:  start,
+		EndsAt:    start.Add(time.Duration(window.Duration) * time.Minute),
+		Recurring: true,
+	}, true
+}
+
+// location 解析时区，为空时使用本地时区
+func location(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
@@ -7,43 +7,235 @@ import (
 
 // Server 服务器模型
 type Server struct {
-	ID             uint            `json:"id" gorm:"primaryKey"`
-	Name           string          `json:"name" gorm:"not null"`
-	Address        string          `json:"address" gorm:"not null"`
-	Port           int             `json:"port" gorm:"not null;default:25565"`
-	Type           string          `json:"type" gorm:"not null"` // "java", "bedrock"
-	Status         string          `json:"status" gorm:"default:offline"`
-	PlayersOnline  int             `json:"players_online" gorm:"default:0"`
-	MaxPlayers     int             `json:"max_players" gorm:"default:0"`
-	AnonymousCount int             `json:"anonymous_count" gorm:"default:0"` // 匿名玩家数量
-	Ping           int             `json:"ping" gorm:"default:0"`
-	Version        string          `json:"version"`
-	MOTD           string          `json:"motd"`
-	Description    string          `json:"description"`
-	LastChecked    *time.Time      `json:"last_checked"`
-	LastOnlineData json.RawMessage `json:"-" gorm:"type:json"`
-	CreatedAt      time.Time       `json:"created_at"`
-	UpdatedAt      time.Time       `json:"updated_at"`
+	ID                  uint            `json:"id" gorm:"primaryKey"`
+	Name                string          `json:"name" gorm:"not null"`
+	Address             string          `json:"address" gorm:"not null"`
+	Port                int             `json:"port" gorm:"not null;default:25565"`
+	Type                string          `json:"type" gorm:"not null"` // "java", "bedrock"
+	Status              string          `json:"status" gorm:"default:offline"`
+	PlayersOnline       int             `json:"players_online" gorm:"default:0"`
+	MaxPlayers          int             `json:"max_players" gorm:"default:0"`
+	AnonymousCount      int             `json:"anonymous_count" gorm:"default:0"` // 匿名玩家数量
+	Ping                int             `json:"ping" gorm:"default:0"`
+	Jitter              float64         `json:"jitter" gorm:"default:0"` // 延迟抖动(毫秒)
+	Version             string          `json:"version"`
+	Release             string          `json:"release" gorm:"index"` // 根据协议号识别的正式版本
+	MOTD                string          `json:"motd"`
+	MOTDComponent       json.RawMessage `json:"motd_component" gorm:"column:motd_component;type:json"` // 结构化的MOTD聊天组件
+	MOTDHTML            string          `json:"motd_html" gorm:"column:motd_html"`                     // 渲染后的MOTD HTML
+	Description         string          `json:"description"`
+	PlayerSource        string          `json:"player_source" gorm:"default:status"` // 玩家列表来源: "status", "query", "rcon"
+	QueryPort           int             `json:"-" gorm:"default:0"`                  // Query端口，0表示与服务器端口相同
+	RconPort            int             `json:"-" gorm:"default:0"`                  // RCON端口，0表示默认的25575
+	RconPassword        string          `json:"-"`                                   // RCON密码 (加密存储)
+	HandshakeHost       string          `json:"-"`                                   // 握手包中发送的主机名，为空时使用服务器地址
+	HandshakeProtocol   int             `json:"handshake_protocol" gorm:"default:0"` // 握手包中声明的协议版本，0表示默认
+	ProxyProtocol       string          `json:"-"`                                   // 发送的PROXY协议头: "", "v1", "v2"
+	LoginCheck          bool            `json:"login_check" gorm:"default:false"`    // 是否进行登录握手检查
+	LoginUsername       string          `json:"-"`                                   // 登录检查使用的玩家名，为空时使用默认值
+	JoinStatus          string          `json:"join_status"`                         // 登录检查结果: "", "joinable", "join-blocked"
+	JoinMessage         string          `json:"join_message"`                        // 登录被拒绝时服务器返回的原因
+	CheckInterval       int             `json:"check_interval" gorm:"default:0"`     // 检查间隔(秒)，0表示使用全局监控间隔
+	CheckTimeout        int             `json:"check_timeout" gorm:"default:0"`      // 单次探测超时(秒)，0表示使用全局超时
+	RetryCount          int             `json:"retry_count" gorm:"default:0"`        // 探测失败后的重试次数
+	FailureThreshold    int             `json:"failure_threshold" gorm:"default:0"`  // 连续失败多少次才判定离线，0表示使用全局配置
+	ConsecutiveFailures int             `json:"-"`                                   // 当前连续失败的检查次数
+	Paused              bool            `json:"paused" gorm:"default:false"`         // 是否暂停监控
+	ModLoader           string          `json:"mod_loader"`
+	Mods                json.RawMessage `json:"-" gorm:"type:json"`
+	ModsHash            string          `json:"-"`
+	EnforcesSecureChat  *bool           `json:"enforces_secure_chat"`
+	PreventsChatReports *bool           `json:"prevents_chat_reports"`
+	FaviconHash         string          `json:"favicon_hash"`
+	LastChecked         *time.Time      `json:"last_checked"`
+	LastOnlineData      json.RawMessage `json:"-" gorm:"type:json"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+
+	// 附加端点 (如Geyser提供的基岩版入口)
+	Endpoints []ServerEndpoint `json:"endpoints,omitempty" gorm:"foreignKey:ServerID"`
+}
+
+// ServerEndpoint 服务器的附加端点 (如Geyser提供的基岩版入口)
+// 服务器自身的地址和端口为主端点，附加端点与主端点共享玩家池
+type ServerEndpoint struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	ServerID      uint       `json:"server_id" gorm:"not null;index"`
+	Edition       string     `json:"edition" gorm:"not null"` // 探测器名称: "java", "bedrock" 等
+	Host          string     `json:"host"`                    // 为空时使用服务器地址
+	Port          int        `json:"port" gorm:"not null"`
+	Status        string     `json:"status" gorm:"default:offline"`
+	PlayersOnline int        `json:"players_online" gorm:"default:0"`
+	MaxPlayers    int        `json:"max_players" gorm:"default:0"`
+	Ping          int        `json:"ping" gorm:"default:0"`
+	Version       string     `json:"version"`
+	LastChecked   *time.Time `json:"last_checked"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// EndpointStat 单个端点在一次检查中的状态，保存在 ServerStat.Endpoints 中
+type EndpointStat struct {
+	EndpointID    uint   `json:"endpoint_id"` // 0 表示服务器主端点
+	Edition       string `json:"edition"`
+	Online        bool   `json:"online"`
+	Ping          int    `json:"ping"`
+	PlayersOnline int    `json:"players_online"`
+	MaxPlayers    int    `json:"max_players"`
+	Version       string `json:"version,omitempty"`
 }
 
 // ServerStat 服务器状态历史
 type ServerStat struct {
-	ID            uint      `json:"id" gorm:"primaryKey"`
-	ServerID      uint      `json:"server_id" gorm:"not null"`
-	PlayersOnline int       `json:"players_online"`
-	MaxPlayers    int       `json:"max_players"`
-	Ping          int       `json:"ping"`
-	Version       string    `json:"version"`
-	MOTD          string    `json:"motd"`
-	Timestamp     time.Time `json:"timestamp"`
-	Server        Server    `json:"server" gorm:"foreignKey:ServerID"`
+	ID            uint            `json:"id" gorm:"primaryKey"`
+	ServerID      uint            `json:"server_id" gorm:"not null"`
+	PlayersOnline int             `json:"players_online"`
+	MaxPlayers    int             `json:"max_players"`
+	Ping          int             `json:"ping"`
+	DNSTime       int             `json:"dns_time"`     // DNS及SRV解析耗时(毫秒)，-1表示未测量
+	ConnectTime   int             `json:"connect_time"` // 建立连接耗时(毫秒)，-1表示未测量
+	StatusTime    int             `json:"status_time"`  // 状态响应耗时(毫秒)，-1表示未测量
+	PingRTT       int             `json:"ping_rtt"`     // ping/pong往返耗时(毫秒)，-1表示未测量
+	Jitter        float64         `json:"jitter"`       // 截至本次检查的延迟抖动(毫秒)
+	JoinStatus    string          `json:"join_status"`  // 登录检查结果，未检查时为空
+	Version       string          `json:"version"`
+	Release       string          `json:"release" gorm:"index"`
+	MOTD          string          `json:"motd"`
+	Endpoints     json.RawMessage `json:"endpoints,omitempty" gorm:"type:json"` // []EndpointStat，仅多端点服务器
+	Timestamp     time.Time       `json:"timestamp"`
+	Server        Server          `json:"server" gorm:"foreignKey:ServerID"`
+
+	// 离线原因，服务器在线时为空
+	FailureCategory string `json:"failure_category,omitempty" gorm:"index"` // 失败分类: dns, srv, connection_refused, timeout 等
+	FailureMessage  string `json:"failure_message,omitempty"`               // 探测返回的错误信息
+	Maintenance     bool   `json:"maintenance,omitempty"`                   // 检查时处于维护窗口，不计入可用率
+}
+
+// ServerEvent 服务器事件记录 (如模组列表变化)
+type ServerEvent struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	ServerID  uint            `json:"server_id" gorm:"not null;index"`
+	EventType string          `json:"event_type" gorm:"not null;index"` // "mods_changed"
+	Message   string          `json:"message"`
+	Data      json.RawMessage `json:"data" gorm:"type:json"`
+	Timestamp time.Time       `json:"timestamp" gorm:"not null;index"`
+}
+
+// MaintenanceWindow 服务器维护窗口
+// 一次性窗口使用开始和结束时间，周期性窗口由cron表达式指定开始时间并持续固定分钟数
+type MaintenanceWindow struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	ServerID  uint       `json:"server_id" gorm:"not null;index"`
+	Title     string     `json:"title"`
+	StartsAt  *time.Time `json:"starts_at"` // 一次性窗口的开始时间
+	EndsAt    *time.Time `json:"ends_at"`   // 一次性窗口的结束时间
+	Cron      string     `json:"cron"`      // 周期性窗口的开始时间 (分 时 日 月 周)
+	Duration  int        `json:"duration"`  // 周期性窗口的持续时间(分钟)
+	Timezone  string     `json:"timezone"`  // 解释cron表达式的时区，为空时使用服务端本地时区
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Incident 服务器停机事件，服务器被确认离线时开始，恢复访问或由管理员处理后结束
+type Incident struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	ServerID        uint       `json:"server_id" gorm:"not null;index"`
+	StartedAt       time.Time  `json:"started_at" gorm:"not null;index"`
+	EndedAt         *time.Time `json:"ended_at" gorm:"index"` // 为空表示停机仍在持续
+	Duration        int64      `json:"duration"`              // 持续时间(秒)，结束时计算
+	FailureCategory string     `json:"failure_category"`      // 开始时的失败分类
+	FailureMessage  string     `json:"failure_message"`       // 开始时的错误信息
+	Notes           string     `json:"notes"`                 // 管理员备注
+	ResolvedBy      string     `json:"resolved_by"`           // 手动结束的管理员，自动恢复时为空
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Server          *Server    `json:"server,omitempty" gorm:"foreignKey:ServerID"`
+}
+
+// AlertRule 告警规则，每次检查后针对适用的服务器评估
+type AlertRule struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Name       string    `json:"name" gorm:"not null"`
+	ServerID   *uint     `json:"server_id" gorm:"index"` // 为空时适用于所有服务器
+	Type       string    `json:"type" gorm:"not null"`   // offline, ping_above, players_above, players_zero, version_changed
+	Threshold  float64   `json:"threshold"`              // 延迟(ms)或玩家数阈值
+	Checks     int       `json:"checks"`                 // 条件需连续满足的检查次数
+	Duration   int       `json:"duration"`               // 条件需持续的分钟数
+	ActiveFrom string    `json:"active_from"`            // 规则生效时段的开始 (HH:MM)，为空时全天生效
+	ActiveTo   string    `json:"active_to"`              // 规则生效时段的结束 (HH:MM)
+	Timezone   string    `json:"timezone"`               // 解释生效时段的时区，为空时使用服务端本地时区
+	Enabled    bool      `json:"enabled" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Alert 告警规则触发的告警，同一规则和服务器同时最多只有一条触发中的告警
+type Alert struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	RuleID     uint       `json:"rule_id" gorm:"not null;index"`
+	ServerID   uint       `json:"server_id" gorm:"not null;index"`
+	State      string     `json:"state" gorm:"not null;index"` // firing, resolved
+	Message    string     `json:"message"`
+	Value      float64    `json:"value"` // 触发时的观测值
+	FiredAt    time.Time  `json:"fired_at" gorm:"not null;index"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Rule       *AlertRule `json:"rule,omitempty" gorm:"foreignKey:RuleID"`
+	Server     *Server    `json:"server,omitempty" gorm:"foreignKey:ServerID"`
+}
+
+// Webhook 外发事件通知的目标，请求体使用密钥进行HMAC-SHA256签名
+type Webhook struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	URL       string    `json:"url" gorm:"not null"`
+	Type      string    `json:"type" gorm:"not null;default:generic"` // generic 发送事件JSON，discord 发送Discord消息
+	Secret    string    `json:"-"`                                    // 加密保存的签名密钥
+	Events    []string  `json:"events" gorm:"serializer:json"`        // 接收的事件类型，为空时接收所有事件
+	ServerIDs []uint    `json:"server_ids" gorm:"serializer:json"`    // 接收事件的服务器，为空时接收所有服务器
+	Enabled   bool      `json:"enabled" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery Webhook的一次事件投递，记录最后一次尝试的结果
+type WebhookDelivery struct {
+	ID         uint            `json:"id" gorm:"primaryKey"`
+	WebhookID  uint            `json:"webhook_id" gorm:"not null;index"`
+	EventID    string          `json:"event_id" gorm:"index"`
+	EventType  string          `json:"event_type"`
+	ServerID   uint            `json:"server_id"`
+	Payload    json.RawMessage `json:"payload" gorm:"type:json"`
+	Status     string          `json:"status" gorm:"not null;index"` // pending, succeeded, failed
+	Attempts   int             `json:"attempts"`
+	StatusCode int             `json:"status_code"` // 最后一次尝试的响应状态码，请求失败时为0
+	Error      string          `json:"error"`
+	Duration   int64           `json:"duration"` // 最后一次尝试的耗时(毫秒)
+	CreatedAt  time.Time       `json:"created_at" gorm:"index"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// Favicon 服务器图标 (按内容哈希去重存储)
+type Favicon struct {
+	Hash      string    `json:"hash" gorm:"primaryKey;size:64"`
+	Data      []byte    `json:"-" gorm:"not null"`
+	Size      int       `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FaviconChange 服务器图标变更记录
+type FaviconChange struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ServerID  uint      `json:"server_id" gorm:"not null;index"`
+	Hash      string    `json:"hash" gorm:"not null;size:64"`
+	ChangedAt time.Time `json:"changed_at" gorm:"not null;index"`
 }
 
 // Player 玩家模型
 type Player struct {
 	ID            uint      `json:"id" gorm:"primaryKey"`
 	Username      string    `json:"username" gorm:"not null;uniqueIndex"`
-	UUID          string    `json:"uuid" gorm:"uniqueIndex"`
+	UUID          string    `json:"uuid" gorm:"uniqueIndex:idx_players_known_uuid,where:uuid <> ''"` // Query和RCON来源只有玩家名，UUID未知时为空
 	FirstSeen     time.Time `json:"first_seen"`
 	LastSeen      time.Time `json:"last_seen"`
 	TotalPlaytime int       `json:"total_playtime"` // 秒
@@ -95,3 +287,29 @@ type User struct {
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
+
+// Agent 远程探测节点
+type Agent struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Name      string     `json:"name" gorm:"unique;not null"`
+	Region    string     `json:"region"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"` // 访问令牌的SHA-256摘要
+	LastSeen  *time.Time `json:"last_seen"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// AgentResult 远程探测节点上报的单次检查结果
+type AgentResult struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	AgentID       uint      `json:"agent_id" gorm:"not null;index"`
+	ServerID      uint      `json:"server_id" gorm:"not null;index"`
+	Online        bool      `json:"online"`
+	Ping          int       `json:"ping"`
+	PlayersOnline int       `json:"players_online"`
+	MaxPlayers    int       `json:"max_players"`
+	Version       string    `json:"version"`
+	Error         string    `json:"error,omitempty"`
+	Timestamp     time.Time `json:"timestamp" gorm:"index"`
+	Agent         Agent     `json:"agent" gorm:"foreignKey:AgentID"`
+}
@@ -0,0 +1,151 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"etamonitor/internal/models"
+	"etamonitor/internal/services"
+)
+
+// endpointResult 单个附加端点的探测结果
+type endpointResult struct {
+	endpoint   *models.ServerEndpoint
+	serverInfo *services.MinecraftServer
+	err        error
+}
+
+// probeEndpoints 并行探测服务器的所有附加端点
+func (s *Service) probeEndpoints(ctx context.Context, server *models.Server) []endpointResult {
+	results := make([]endpointResult, len(server.Endpoints))
+
+	var wg sync.WaitGroup
+	for i := range server.Endpoints {
+		endpoint := &server.Endpoints[i]
+		results[i].endpoint = endpoint
+
+		host := endpoint.Host
+		if host == "" {
+			host = server.Address
+		}
+
+		wg.Add(1)
+		go func(result *endpointResult) {
+			defer wg.Done()
+			prober := services.ProberFor(endpoint.Edition)
+			result.serverInfo, result.err = prober.Probe(ctx, services.ProbeTarget{Host: host, Port: endpoint.Port})
+		}(&results[i])
+	}
+	wg.Wait()
+
+	return results
+}
+
+// mergeEndpointResults 合并主端点与附加端点的探测结果
+// 各端点共享同一个玩家池 (如Geyser)，因此人数取最大值而不是相加，玩家列表取并集
+// 主端点离线时使用第一个在线的附加端点作为基础信息
+func mergeEndpointResults(primary *services.MinecraftServer, results []endpointResult) *services.MinecraftServer {
+	var merged *services.MinecraftServer
+	if primary != nil {
+		copied := *primary
+		merged = &copied
+	}
+
+	for _, result := range results {
+		if result.err != nil || result.serverInfo == nil {
+			continue
+		}
+		if merged == nil {
+			copied := *result.serverInfo
+			merged = &copied
+			continue
+		}
+
+		if result.serverInfo.Players.Online > merged.Players.Online {
+			merged.Players.Online = result.serverInfo.Players.Online
+		}
+		if result.serverInfo.Players.Max > merged.Players.Max {
+			merged.Players.Max = result.serverInfo.Players.Max
+		}
+		merged.Players.Sample = unionPlayers(merged.Players.Sample, result.serverInfo.Players.Sample)
+	}
+
+	return merged
+}
+
+// unionPlayers 合并两个玩家列表，按玩家名去重
+func unionPlayers(a, b []services.PlayerInfo) []services.PlayerInfo {
+	if len(b) == 0 {
+		return a
+	}
+
+	seen := make(map[string]bool, len(a)+len(b))
+	result := make([]services.PlayerInfo, 0, len(a)+len(b))
+	for _, list := range [][]services.PlayerInfo{a, b} {
+		for _, player := range list {
+			key := strings.ToLower(player.Name)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, player)
+		}
+	}
+	return result
+}
+
+// saveEndpointResults 更新各附加端点的状态，并返回本次检查中所有端点的状态 (含主端点)
+func (s *Service) saveEndpointResults(server *models.Server, primary *services.MinecraftServer, results []endpointResult, checkedAt time.Time) json.RawMessage {
+	stats := make([]models.EndpointStat, 0, len(results)+1)
+	stats = append(stats, newEndpointStat(0, server.Type, primary))
+
+	for _, result := range results {
+		var serverInfo *services.MinecraftServer
+		if result.err == nil {
+			serverInfo = result.serverInfo
+		} else {
+			log.Printf("Failed to ping %s endpoint on port %d of server %s: %v",
+				result.endpoint.Edition, result.endpoint.Port, server.Name, result.err)
+		}
+
+		stat := newEndpointStat(result.endpoint.ID, result.endpoint.Edition, serverInfo)
+		stats = append(stats, stat)
+
+		status := "offline"
+		if stat.Online {
+			status = "online"
+		}
+		s.db.Model(result.endpoint).Updates(map[string]interface{}{
+			"status":         status,
+			"players_online": stat.PlayersOnline,
+			"max_players":    stat.MaxPlayers,
+			"ping":           stat.Ping,
+			"version":        stat.Version,
+			"last_checked":   &checkedAt,
+		})
+	}
+
+	data, _ := json.Marshal(stats)
+	return data
+}
+
+// newEndpointStat 根据探测结果创建端点状态，serverInfo为nil表示离线
+func newEndpointStat(endpointID uint, edition string, serverInfo *services.MinecraftServer) models.EndpointStat {
+	stat := models.EndpointStat{
+		EndpointID: endpointID,
+		Edition:    edition,
+		Ping:       -1,
+	}
+	if serverInfo != nil {
+		stat.Online = true
+		stat.Ping = serverInfo.Ping
+		stat.PlayersOnline = serverInfo.Players.Online
+		stat.MaxPlayers = serverInfo.Players.Max
+		stat.Version = serverInfo.Version.Name
+	}
+	return stat
+}
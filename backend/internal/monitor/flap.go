@@ -0,0 +1,79 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+)
+
+// 状态抖动检测参数
+// 最近若干次检查中结果发生变化的比例达到上限时进入降级状态，降到下限以下才恢复，避免在两种状态间反复切换
+const (
+	flapHistorySize   = 20
+	flapMinHistory    = 5
+	flapHighThreshold = 0.3
+	flapLowThreshold  = 0.15
+)
+
+// flapDetector 根据最近的检查结果判断服务器是否处于状态抖动
+type flapDetector struct {
+	mu       sync.Mutex
+	history  map[uint][]bool
+	flapping map[uint]bool
+}
+
+func newFlapDetector() *flapDetector {
+	return &flapDetector{
+		history:  make(map[uint][]bool),
+		flapping: make(map[uint]bool),
+	}
+}
+
+// record 记录一次检查结果 (重试之后，离线确认之前)，返回服务器当前是否处于抖动状态
+func (f *flapDetector) record(serverID uint, success bool) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	history := append(f.history[serverID], success)
+	if len(history) > flapHistorySize {
+		history = history[len(history)-flapHistorySize:]
+	}
+	f.history[serverID] = history
+
+	if len(history) < flapMinHistory {
+		return f.flapping[serverID]
+	}
+
+	changes := 0
+	for i := 1; i < len(history); i++ {
+		if history[i] != history[i-1] {
+			changes++
+		}
+	}
+	ratio := float64(changes) / float64(len(history)-1)
+
+	switch {
+	case ratio >= flapHighThreshold:
+		f.flapping[serverID] = true
+	case ratio < flapLowThreshold:
+		f.flapping[serverID] = false
+	}
+	return f.flapping[serverID]
+}
+
+// retain 清除不在调度中 (已删除) 的服务器的检查记录
+func (f *flapDetector) retain(servers map[uint]time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for id := range f.history {
+		if _, ok := servers[id]; !ok {
+			delete(f.history, id)
+			delete(f.flapping, id)
+		}
+	}
+}
+
+// isUp 服务器当前是否被视为可访问 (在线或降级)
+func isUp(status string) bool {
+	return status == statusOnline || status == statusDegraded
+}
@@ -0,0 +1,61 @@
+package monitor
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"etamonitor/internal/events"
+	"etamonitor/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// openIncident 服务器被确认离线时开始一次停机事件，已有未结束的事件时不重复创建
+func (s *Service) openIncident(server *models.Server, stat *models.ServerStat) {
+	var open models.Incident
+	err := s.db.Where("server_id = ? AND ended_at IS NULL", server.ID).First(&open).Error
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Failed to look up open incident for %s: %v", server.Name, err)
+		return
+	}
+
+	incident := models.Incident{
+		ServerID:        server.ID,
+		StartedAt:       stat.Timestamp,
+		FailureCategory: stat.FailureCategory,
+		FailureMessage:  stat.FailureMessage,
+	}
+	if err := s.db.Create(&incident).Error; err != nil {
+		log.Printf("Failed to open incident for %s: %v", server.Name, err)
+		return
+	}
+	log.Printf("Incident %d opened for %s (%s)", incident.ID, server.Name, incident.FailureCategory)
+	events.Publish(events.IncidentOpened, server.ID, events.NewIncidentData(incident, server))
+}
+
+// closeIncident 服务器恢复访问时结束未结束的停机事件
+func (s *Service) closeIncident(server *models.Server, now time.Time) {
+	var open models.Incident
+	err := s.db.Where("server_id = ? AND ended_at IS NULL", server.ID).First(&open).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Failed to look up open incident for %s: %v", server.Name, err)
+		}
+		return
+	}
+
+	duration := now.Sub(open.StartedAt)
+	if err := s.db.Model(&open).Updates(map[string]interface{}{
+		"ended_at": now,
+		"duration": int64(duration.Seconds()),
+	}).Error; err != nil {
+		log.Printf("Failed to close incident %d for %s: %v", open.ID, server.Name, err)
+		return
+	}
+	log.Printf("Incident %d for %s closed after %v", open.ID, server.Name, duration.Round(time.Second))
+	events.Publish(events.IncidentResolved, server.ID, events.NewIncidentData(open, server))
+}
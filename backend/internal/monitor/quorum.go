@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"time"
+
+	"etamonitor/internal/models"
+)
+
+// minAgentResultAge 远程探测结果的最短有效期，避免检查间隔较短时忽略探测节点
+const minAgentResultAge = time.Minute
+
+// confirmOffline 本地探测失败后，结合远程探测节点的最新结果判断服务器是否离线
+// 本地和每个有近期结果的探测节点各算一个探测点，报告不可达的探测点数量达到法定数量才判定离线
+// 返回是否判定离线、报告不可达的探测点数量及探测点总数
+func (s *Service) confirmOffline(server *models.Server) (bool, int, int) {
+	// 探测节点按服务器的检查间隔上报结果，有效期随之调整
+	maxAge := 3 * s.checkInterval(server.CheckInterval)
+	if maxAge < minAgentResultAge {
+		maxAge = minAgentResultAge
+	}
+
+	var results []models.AgentResult
+	s.db.Where("server_id = ? AND timestamp > ?", server.ID, time.Now().Add(-maxAge)).
+		Order("timestamp desc").
+		Find(&results)
+
+	// 本地探测已失败
+	total, down := 1, 1
+	seen := make(map[uint]bool)
+	for _, result := range results {
+		if seen[result.AgentID] {
+			continue
+		}
+		seen[result.AgentID] = true
+		total++
+		if !result.Online {
+			down++
+		}
+	}
+
+	quorum := s.config.AgentQuorum
+	if quorum <= 0 {
+		quorum = total/2 + 1
+	}
+	if quorum > total {
+		quorum = total
+	}
+
+	return down >= quorum, down, total
+}
@@ -0,0 +1,263 @@
+package monitor
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// jitterFraction 每次计划检查时间的随机偏移比例，避免同一间隔的服务器始终同时检查
+const jitterFraction = 0.1
+
+// scheduleEntry 单个服务器的调度状态
+type scheduleEntry struct {
+	interval    time.Duration // 检查间隔
+	next        time.Time     // 下一次计划检查的时间
+	scheduledAt time.Time     // 当前检查的计划时间
+	startedAt   time.Time     // 当前检查实际开始探测的时间
+	running     bool          // 检查是否仍在进行 (包括等待并发名额)
+	missed      uint64
+	late        uint64
+	lastDelay   time.Duration
+}
+
+// scheduler 服务器检查调度器
+// 每个服务器同一时间最多只有一个检查在进行，计划时间带有随机偏移以分散检查
+type scheduler struct {
+	mu      sync.Mutex
+	entries map[uint]*scheduleEntry
+
+	started    uint64
+	completed  uint64
+	missed     uint64
+	late       uint64
+	totalDelay time.Duration
+	maxDelay   time.Duration
+	totalTime  time.Duration
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{entries: make(map[uint]*scheduleEntry)}
+}
+
+// due 根据当前时间和各服务器的检查间隔，返回需要开始检查的服务器ID
+// 新加入的服务器在一个间隔内随机分布；到期时上一次检查仍未结束的，记为错过并顺延
+func (sc *scheduler) due(now time.Time, intervals map[uint]time.Duration) []uint {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	// 清理已删除服务器的调度状态
+	for id := range sc.entries {
+		if _, ok := intervals[id]; !ok {
+			delete(sc.entries, id)
+		}
+	}
+
+	var due []uint
+	for id, interval := range intervals {
+		entry, ok := sc.entries[id]
+		if !ok {
+			entry = &scheduleEntry{interval: interval, next: now.Add(randomDuration(interval))}
+			sc.entries[id] = entry
+		}
+		if entry.interval != interval {
+			// 间隔缩短时不必等到原计划时间
+			entry.interval = interval
+			if limit := now.Add(interval); entry.next.After(limit) {
+				entry.next = limit
+			}
+		}
+		if now.Before(entry.next) {
+			continue
+		}
+
+		scheduledAt := entry.next
+		entry.next = nextCheckTime(entry.next, now, interval)
+		if now.Sub(scheduledAt) >= interval {
+			// 调度循环本身被阻塞 (如系统休眠)，期间的检查已经错过
+			entry.missed++
+			sc.missed++
+		}
+		if entry.running {
+			entry.missed++
+			sc.missed++
+			continue
+		}
+
+		entry.running = true
+		entry.scheduledAt = scheduledAt
+		entry.startedAt = time.Time{}
+		due = append(due, id)
+	}
+
+	sort.Slice(due, func(i, j int) bool { return due[i] < due[j] })
+	return due
+}
+
+// claim 将服务器标记为检查中并立即开始检查，下一次计划检查从现在起重新计算
+// 服务器已有检查在进行时返回false
+func (sc *scheduler) claim(id uint, interval time.Duration, now time.Time) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	entry, ok := sc.entries[id]
+	if !ok {
+		entry = &scheduleEntry{interval: interval}
+		sc.entries[id] = entry
+	}
+	if entry.running {
+		return false
+	}
+
+	entry.interval = interval
+	entry.next = nextCheckTime(now, now, interval)
+	entry.running = true
+	entry.scheduledAt = now
+	entry.startedAt = time.Time{}
+	return true
+}
+
+// begin 记录检查实际开始探测 (已获得并发名额) 的时间
+// 与计划时间相差超过半个间隔的检查记为延迟
+func (sc *scheduler) begin(id uint, now time.Time) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	entry, ok := sc.entries[id]
+	if !ok || !entry.running {
+		return
+	}
+
+	delay := now.Sub(entry.scheduledAt)
+	if delay < 0 {
+		delay = 0
+	}
+	entry.startedAt = now
+	entry.lastDelay = delay
+
+	sc.started++
+	sc.totalDelay += delay
+	if delay > sc.maxDelay {
+		sc.maxDelay = delay
+	}
+	if delay > entry.interval/2 {
+		entry.late++
+		sc.late++
+	}
+}
+
+// finish 记录检查结束，之后该服务器才能开始下一次检查
+func (sc *scheduler) finish(id uint, now time.Time) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	entry, ok := sc.entries[id]
+	if !ok || !entry.running {
+		return
+	}
+	entry.running = false
+	if !entry.startedAt.IsZero() {
+		sc.completed++
+		sc.totalTime += now.Sub(entry.startedAt)
+	}
+}
+
+// retryAt 将服务器的下一次检查提前到指定时间，用于尽快确认检查失败
+// 原计划时间更早时保持不变
+func (sc *scheduler) retryAt(id uint, at time.Time) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if entry, ok := sc.entries[id]; ok && at.Before(entry.next) {
+		entry.next = at
+	}
+}
+
+// nextCheckTime 计算下一次计划检查时间
+// 按间隔从上一次计划时间顺延以保持节奏，落后超过一个间隔时 (如系统休眠) 从当前时间重新计算
+func nextCheckTime(previous, now time.Time, interval time.Duration) time.Time {
+	next := previous.Add(interval)
+	if !next.After(now) {
+		next = now.Add(interval)
+	}
+	if spread := time.Duration(float64(interval) * jitterFraction); spread > 0 {
+		next = next.Add(randomDuration(2*spread) - spread)
+	}
+	return next
+}
+
+// randomDuration 返回 [0, d) 内的随机时长
+func randomDuration(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+// SchedulerMetrics 调度器的运行指标
+type SchedulerMetrics struct {
+	Servers        int                     `json:"servers"`         // 参与调度的服务器数量
+	Running        int                     `json:"running"`         // 正在进行的检查数量 (包括等待并发名额的检查)
+	MaxConcurrent  int                     `json:"max_concurrent"`  // 并发检查数上限
+	Started        uint64                  `json:"started"`         // 已开始探测的检查次数
+	Completed      uint64                  `json:"completed"`       // 已完成的检查次数
+	Missed         uint64                  `json:"missed"`          // 到期时上一次检查仍未结束而跳过的次数
+	Late           uint64                  `json:"late"`            // 开始时间比计划晚半个间隔以上的次数
+	AvgDelayMs     int64                   `json:"avg_delay_ms"`    // 计划时间到开始探测的平均延迟
+	MaxDelayMs     int64                   `json:"max_delay_ms"`    // 计划时间到开始探测的最大延迟
+	AvgDurationMs  int64                   `json:"avg_duration_ms"` // 检查的平均耗时
+	ServerSchedule []ServerScheduleMetrics `json:"server_schedule"` // 各服务器的调度状态
+}
+
+// ServerScheduleMetrics 单个服务器的调度状态
+type ServerScheduleMetrics struct {
+	ServerID    uint      `json:"server_id"`
+	Interval    string    `json:"interval"`
+	NextCheck   time.Time `json:"next_check"`
+	Running     bool      `json:"running"`
+	Missed      uint64    `json:"missed"`
+	Late        uint64    `json:"late"`
+	LastDelayMs int64     `json:"last_delay_ms"`
+}
+
+// metrics 返回调度器当前的运行指标
+func (sc *scheduler) metrics() SchedulerMetrics {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	m := SchedulerMetrics{
+		Servers:        len(sc.entries),
+		Started:        sc.started,
+		Completed:      sc.completed,
+		Missed:         sc.missed,
+		Late:           sc.late,
+		MaxDelayMs:     sc.maxDelay.Milliseconds(),
+		ServerSchedule: make([]ServerScheduleMetrics, 0, len(sc.entries)),
+	}
+	if sc.started > 0 {
+		m.AvgDelayMs = (sc.totalDelay / time.Duration(sc.started)).Milliseconds()
+	}
+	if sc.completed > 0 {
+		m.AvgDurationMs = (sc.totalTime / time.Duration(sc.completed)).Milliseconds()
+	}
+
+	for id, entry := range sc.entries {
+		if entry.running {
+			m.Running++
+		}
+		m.ServerSchedule = append(m.ServerSchedule, ServerScheduleMetrics{
+			ServerID:    id,
+			Interval:    entry.interval.String(),
+			NextCheck:   entry.next,
+			Running:     entry.running,
+			Missed:      entry.missed,
+			Late:        entry.late,
+			LastDelayMs: entry.lastDelay.Milliseconds(),
+		})
+	}
+	sort.Slice(m.ServerSchedule, func(i, j int) bool {
+		return m.ServerSchedule[i].ServerID < m.ServerSchedule[j].ServerID
+	})
+	return m
+}
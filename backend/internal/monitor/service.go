@@ -2,11 +2,17 @@ package monitor
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
 	"sync"
 	"time"
 
+	"etamonitor/internal/alerting"
+	"etamonitor/internal/auth"
 	"etamonitor/internal/config"
+	"etamonitor/internal/events"
+	"etamonitor/internal/maintenance"
 	"etamonitor/internal/models"
 	"etamonitor/internal/services"
 	"etamonitor/internal/websocket"
@@ -18,32 +24,67 @@ type Service struct {
 	db                   *gorm.DB
 	config               *config.Config
 	playerSessionService *services.PlayerSessionService
+	faviconService       *services.FaviconService
 	
 	// 并发控制
 	semaphore            chan struct{} // 控制并发goroutine数量
 	ctx                  context.Context
 	cancel               context.CancelFunc
 	wg                   sync.WaitGroup
+
+	// 检查调度
+	scheduler            *scheduler
+	flaps                *flapDetector
+	priority             chan uint // 需要立即检查的服务器ID
+
+	// 告警规则评估
+	alerts               *alerting.Engine
 }
 
+// schedulerTick 调度循环的间隔，决定各服务器检查时间的精度
+const schedulerTick = time.Second
+
+// retryDelay 探测失败后重试前的等待时间
+const retryDelay = time.Second
+
+// priorityQueueSize 等待立即检查的服务器数量上限，超出时由常规调度检查
+const priorityQueueSize = 64
+
+// 服务器状态
+const (
+	statusOnline      = "online"
+	statusDegraded    = "degraded"    // 可以访问，但检查结果频繁在成功与失败之间变化
+	statusOffline     = "offline"
+	statusMaintenance = "maintenance" // 维护窗口内无法访问
+	statusPaused      = "paused"      // 已手动暂停监控
+)
+
 func NewService(db *gorm.DB, cfg *config.Config) *Service {
 	ctx, cancel := context.WithCancel(context.Background())
 	
-	// 限制最大并发检查数为10个
-	maxConcurrent := 10
+	// 限制最大并发检查数
+	maxConcurrent := cfg.MaxConcurrent
+	if maxConcurrent <= 0 {
+		maxConcurrent = 10
+	}
 	
 	return &Service{
 		db:                   db,
 		config:               cfg,
 		playerSessionService: services.NewPlayerSessionService(db),
+		faviconService:       services.NewFaviconService(db),
 		semaphore:            make(chan struct{}, maxConcurrent),
 		ctx:                  ctx,
 		cancel:               cancel,
+		scheduler:            newScheduler(),
+		flaps:                newFlapDetector(),
+		priority:             make(chan uint, priorityQueueSize),
+		alerts:               alerting.NewEngine(db),
 	}
 }
 
 func (s *Service) Start() {
-	ticker := time.NewTicker(s.config.MonitorInterval)
+	ticker := time.NewTicker(schedulerTick)
 	defer ticker.Stop()
 
 	// 启动时清理旧数据
@@ -53,7 +94,8 @@ func (s *Service) Start() {
 	cleanupTicker := time.NewTicker(1 * time.Hour)
 	defer cleanupTicker.Stop()
 
-	log.Printf("Server monitoring started with interval: %v", s.config.MonitorInterval)
+	log.Printf("Server monitoring started with default interval: %v, max concurrent checks: %d",
+		s.config.MonitorInterval, cap(s.semaphore))
 
 	for {
 		select {
@@ -63,8 +105,10 @@ func (s *Service) Start() {
 			s.wg.Wait()
 			log.Println("Monitor service stopped")
 			return
-		case <-ticker.C:
-			s.checkAllServers()
+		case now := <-ticker.C:
+			s.checkDueServers(now)
+		case serverID := <-s.priority:
+			s.checkNow(serverID)
 		case <-cleanupTicker.C:
 			s.cleanupOldStats()
 		}
@@ -76,33 +120,108 @@ func (s *Service) Stop() {
 	s.cancel()
 }
 
-func (s *Service) checkAllServers() {
+// Metrics 返回检查调度器的运行指标
+func (s *Service) Metrics() SchedulerMetrics {
+	metrics := s.scheduler.metrics()
+	metrics.MaxConcurrent = cap(s.semaphore)
+	return metrics
+}
+
+// EnqueueCheck 请求立即检查服务器，不必等待下一次调度
+// 用于服务器创建或连接配置变更后尽快得到状态，检查结果通过WebSocket推送
+func (s *Service) EnqueueCheck(serverID uint) {
+	select {
+	case s.priority <- serverID:
+	default:
+		log.Printf("Priority check queue is full, server %d will be checked on schedule", serverID)
+	}
+}
+
+// checkNow 立即开始检查服务器，并从此时起重新计算检查间隔
+// 服务器已有检查在进行时，在其结束后尽快再检查一次，以使用最新的配置
+func (s *Service) checkNow(serverID uint) {
+	var server models.Server
+	if err := s.db.Preload("Endpoints").First(&server, serverID).Error; err != nil {
+		log.Printf("Failed to fetch server %d for priority check: %v", serverID, err)
+		return
+	}
+
+	now := time.Now()
+	if !s.scheduler.claim(server.ID, s.checkInterval(server.CheckInterval), now) {
+		s.scheduler.retryAt(server.ID, now)
+		return
+	}
+
+	log.Printf("Checking %s immediately", server.Name)
+	s.startCheck(server)
+}
+
+// checkDueServers 检查所有已到检查时间的服务器
+// 每个服务器按自身的检查间隔调度，未设置时使用全局监控间隔
+func (s *Service) checkDueServers(now time.Time) {
+	var schedules []struct {
+		ID            uint
+		CheckInterval int
+	}
+	if err := s.db.Model(&models.Server{}).Select("id, check_interval").Find(&schedules).Error; err != nil {
+		log.Printf("Failed to fetch server schedules: %v", err)
+		return
+	}
+
+	intervals := make(map[uint]time.Duration, len(schedules))
+	for _, schedule := range schedules {
+		intervals[schedule.ID] = s.checkInterval(schedule.CheckInterval)
+	}
+
+	s.flaps.retain(intervals)
+	due := s.scheduler.due(now, intervals)
+	if len(due) == 0 {
+		return
+	}
+
 	var servers []models.Server
-	if err := s.db.Find(&servers).Error; err != nil {
+	if err := s.db.Preload("Endpoints").Find(&servers, due).Error; err != nil {
 		log.Printf("Failed to fetch servers: %v", err)
+		servers = nil
+	}
+
+	// 未能加载的服务器 (查询失败或已被删除) 结束本次检查，避免一直处于检查中
+	loaded := make(map[uint]bool, len(servers))
+	for _, server := range servers {
+		loaded[server.ID] = true
+	}
+	for _, id := range due {
+		if !loaded[id] {
+			s.scheduler.finish(id, now)
+		}
+	}
+	if len(servers) == 0 {
 		return
 	}
 
 	log.Printf("Checking %d servers...", len(servers))
 	
 	for _, server := range servers {
-		// 复制server变量避免闭包问题
-		serverCopy := server
-		
-		s.wg.Add(1)
-		go func() {
-			defer s.wg.Done()
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("Panic in server check for %s: %v", serverCopy.Name, r)
-				}
-			}()
-			
-			s.checkServerWithTimeout(&serverCopy)
-		}()
+		s.startCheck(server)
 	}
 }
 
+// startCheck 在新的goroutine中检查服务器，调用前需已在调度器中标记为检查中
+func (s *Service) startCheck(server models.Server) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() { s.scheduler.finish(server.ID, time.Now()) }()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Panic in server check for %s: %v", server.Name, r)
+			}
+		}()
+		
+		s.checkServerWithTimeout(&server)
+	}()
+}
+
 func (s *Service) checkServerWithTimeout(server *models.Server) {
 	// 获取信号量，限制并发数
 	select {
@@ -111,129 +230,514 @@ func (s *Service) checkServerWithTimeout(server *models.Server) {
 	case <-s.ctx.Done():
 		return
 	}
+	s.scheduler.begin(server.ID, time.Now())
 	
-	// 设置超时上下文
-	ctx, cancel := context.WithTimeout(s.ctx, 30*time.Second)
-	defer cancel()
-	
-	done := make(chan struct{})
-	go func() {
-		defer close(done)
-		s.checkServer(server)
-	}()
-	
-	select {
-	case <-done:
-		// 正常完成
-	case <-ctx.Done():
-		log.Printf("Server check timeout for %s", server.Name)
-	}
+	// 探测在超时或服务停止时会关闭连接并返回，无需额外的超时协程
+	s.checkServer(server)
 }
 
 func (s *Service) checkServer(server *models.Server) {
 	var serverInfo *services.MinecraftServer
 	var err error
 
-	// 根据服务器类型进行ping
-	switch server.Type {
-	case "java":
-		serverInfo, err = services.JavaServerPing(server.Address, server.Port)
-	case "bedrock":
-		serverInfo, err = services.BedrockServerPing(server.Address, server.Port)
-	case "auto":
-		var detectedType string
-		serverInfo, detectedType, err = services.AutoDetectServer(
-			server.Address,
-			server.Port,
-			19132, // 基岩版默认端口
-		)
-		if err == nil && detectedType != "" {
-			// 更新检测到的服务器类型
-			s.db.Model(server).Update("type", detectedType)
-		}
-	default:
-		// 默认尝试Java版
-		serverInfo, err = services.JavaServerPing(server.Address, server.Port)
+	// 暂停监控的服务器不进行探测
+	if server.Paused {
+		s.markPaused(server)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.CheckTimeout(server))
+	defer cancel()
+
+	// 附加端点与主端点并行探测
+	var endpointResults []endpointResult
+	endpointsDone := make(chan struct{})
+	go func() {
+		defer close(endpointsDone)
+		endpointResults = s.probeEndpoints(ctx, server)
+	}()
+
+	serverInfo, err = s.probeWithRetry(server)
+	if err == nil && server.Type == "auto" && serverInfo.ServerType != services.Unknown {
+		// 更新检测到的服务器类型
+		s.db.Model(server).Update("type", serverInfo.ServerType.String())
+	}
+	<-endpointsDone
+
+	// 监控服务停止导致的失败不代表服务器离线
+	if s.ctx.Err() != nil {
+		return
 	}
 
 	// 创建统计记录
 	stat := models.ServerStat{
-		ServerID:  server.ID,
-		Timestamp: time.Now(),
+		ServerID:    server.ID,
+		DNSTime:     -1,
+		ConnectTime: -1,
+		StatusTime:  -1,
+		PingRTT:     -1,
+		Jitter:      server.Jitter,
+		Timestamp:   time.Now(),
+	}
+
+	// 多端点服务器: 记录各端点状态，任一端点在线即视为服务器在线
+	if len(server.Endpoints) > 0 {
+		var primary *services.MinecraftServer
+		if err == nil {
+			primary = serverInfo
+		}
+		stat.Endpoints = s.saveEndpointResults(server, primary, endpointResults, stat.Timestamp)
+		if merged := mergeEndpointResults(primary, endpointResults); merged != nil {
+			serverInfo, err = merged, nil
+		}
+	}
+
+	// 维护窗口内的检查不参与抖动检测和离线确认，失败时记为维护中
+	inMaintenance := s.inMaintenance(server, stat.Timestamp)
+	stat.Maintenance = inMaintenance
+
+	// 记录本次结果，结果频繁变化的服务器标记为降级
+	var flapping bool
+	var unconfirmed bool // 本地探测失败，但其他探测点仍可访问
+	if !inMaintenance {
+		flapping = s.flaps.record(server.ID, err == nil)
+	}
+
+	if err != nil && !inMaintenance {
+		failures := server.ConsecutiveFailures + 1
+		s.db.Model(server).Update("consecutive_failures", failures)
+
+		// 可访问的服务器需连续失败达到阈值才判定离线，期间尽快重新检查以确认，短暂的丢包不会中断玩家会话
+		if threshold := s.failureThreshold(server); isUp(server.Status) && failures < threshold {
+			log.Printf("Check of %s failed (%d of %d failures before marking offline): %v",
+				server.Name, failures, threshold, err)
+			s.scheduler.retryAt(server.ID, time.Now().Add(s.config.ConfirmInterval))
+			return
+		}
+
+		// 本地探测失败时，需由足够多的探测点确认才判定离线，以区分本地网络问题
+		if confirmed, down, total := s.confirmOffline(server); !confirmed {
+			log.Printf("Local check of %s failed (%v), but only %d of %d vantage points report it unreachable",
+				server.Name, err, down, total)
+			unconfirmed = true
+		}
 	}
 
 	// 确定服务器状态
-	wasOnline := server.Status == "online"
+	wasOnline := isUp(server.Status)
+	previousStatus := server.Status // 更新数据库时会同时修改 server 的字段
+	previousVersion := server.Version
+	status := statusOffline
+	if err == nil && serverInfo != nil {
+		status = statusOnline
+	}
+	if status == statusOnline && flapping {
+		status = statusDegraded
+	}
+	// 离线未得到确认时仍记录本次失败，但服务器视为可访问，记为降级
+	if unconfirmed {
+		status = statusDegraded
+	}
+	if status == statusOffline && inMaintenance {
+		status = statusMaintenance
+	}
+	if status != server.Status {
+		log.Printf("Server %s status changed: %s -> %s", server.Name, server.Status, status)
+	}
 
 	if err == nil && serverInfo != nil {
 		stat.PlayersOnline = serverInfo.Players.Online
 		stat.MaxPlayers = serverInfo.Players.Max
 		stat.Ping = serverInfo.Ping
+		stat.DNSTime = serverInfo.Latency.DNS
+		stat.ConnectTime = serverInfo.Latency.Connect
+		stat.StatusTime = serverInfo.Latency.Status
+		stat.PingRTT = serverInfo.Latency.Ping
+		// 与上一次成功检查的延迟比较，平滑计算抖动；之前不可访问 (新添加、离线、暂停等) 时没有可比较的延迟
+		prevPing := server.Ping
+		if !wasOnline {
+			prevPing = -1
+		}
+		stat.Jitter = services.Jitter(server.Jitter, prevPing, serverInfo.Ping)
 		stat.Version = serverInfo.Version.Name
+		stat.Release = serverInfo.Release
 		stat.MOTD = extractDescriptionText(serverInfo.Description)
 
+		// 状态查询正常时进一步检查是否真正可以加入
+		joinStatus, joinMessage := s.checkJoin(server, serverInfo)
+		stat.JoinStatus = joinStatus
+		if joinStatus == services.JoinStatusBlocked && server.JoinStatus != services.JoinStatusBlocked {
+			log.Printf("Server %s answers status pings but rejects joins: %s", server.Name, joinMessage)
+		}
+
 		// 更新玩家会话记录
-		s.playerSessionService.UpdatePlayerSessions(server, serverInfo.Players.Sample)
+		if players, ok := s.resolvePlayerList(server, serverInfo); ok {
+			s.playerSessionService.UpdatePlayerSessions(server, players)
+		}
+
+		// 保存结构化的MOTD，供前端按游戏内样式显示
+		motdComponent, _ := json.Marshal(serverInfo.Description)
+		motdHTML := serverInfo.Description.HTML()
 
 		// 更新服务器的实时信息
 		serverUpdates := map[string]interface{}{
-			"status":          "online",
+			"status":          status,
 			"players_online":  serverInfo.Players.Online,
 			"max_players":     serverInfo.Players.Max,
 			"anonymous_count": s.playerSessionService.GetAnonymousCount(server.ID),
 			"ping":            serverInfo.Ping,
+			"jitter":          stat.Jitter,
 			"version":         serverInfo.Version.Name,
+			"release":         serverInfo.Release,
 			"motd":            extractDescriptionText(serverInfo.Description),
+			"motd_component":  motdComponent,
+			"motd_html":       motdHTML,
+			"join_status":     joinStatus,
+			"join_message":    joinMessage,
 			"last_checked":    &stat.Timestamp,
 		}
+		if server.ConsecutiveFailures != 0 {
+			serverUpdates["consecutive_failures"] = 0
+		}
 		// 保存这些信息作为最后一次在线状态
 		s.db.Model(server).Update("last_online_data", serverInfo)
 		s.db.Model(server).Updates(serverUpdates)
 
+		// 从离线、维护等状态恢复时结束停机事件
+		if previousStatus != statusOnline {
+			s.closeIncident(server, stat.Timestamp)
+		}
+
+		// 更新模组服务器信息
+		s.updateModInfo(server, serverInfo)
+
+		// 保存服务器图标
+		if _, err := s.faviconService.Store(server, serverInfo.Favicon); err != nil {
+			log.Printf("Failed to store favicon for %s: %v", server.Name, err)
+		}
+
 		// 广播服务器状态更新
 		s.broadcastServerStatus(server.ID, map[string]interface{}{
 			"id":              server.ID,
 			"name":            server.Name,
-			"status":          "online",
+			"status":          status,
 			"players_online":  serverInfo.Players.Online,
 			"max_players":     serverInfo.Players.Max,
 			"anonymous_count": s.playerSessionService.GetAnonymousCount(server.ID),
 			"ping":            serverInfo.Ping,
+			"latency":         serverInfo.Latency,
+			"jitter":          stat.Jitter,
 			"version":         serverInfo.Version.Name,
+			"release":         serverInfo.Release,
 			"motd":            extractDescriptionText(serverInfo.Description),
+			"motd_component":  serverInfo.Description,
+			"motd_html":       motdHTML,
+			"join_status":     joinStatus,
+			"join_message":    joinMessage,
 		})
 	} else {
-		log.Printf("Failed to ping server %s: %v", server.Name, err)
 		stat.Ping = -1
+		stat.FailureCategory = string(services.ClassifyFailure(err))
+		stat.FailureMessage = truncateFailureMessage(err.Error())
+		log.Printf("Failed to ping server %s (%s): %v", server.Name, stat.FailureCategory, err)
 
-		// 服务器离线时，使用玩家会话服务清理会话
-		if wasOnline {
-			s.playerSessionService.UpdatePlayerSessions(server, []services.PlayerInfo{})
-		}
+		if unconfirmed {
+			// 未确认离线时保留玩家会话和最后一次在线的数据，只更新状态
+			s.db.Model(server).Updates(map[string]interface{}{
+				"status":       status,
+				"last_checked": &stat.Timestamp,
+			})
+			s.broadcastServerStatus(server.ID, map[string]interface{}{
+				"id":     server.ID,
+				"name":   server.Name,
+				"status": status,
+			})
+		} else {
+			// 服务器离线时，使用玩家会话服务清理会话
+			if wasOnline {
+				s.playerSessionService.UpdatePlayerSessions(server, []services.PlayerInfo{})
+			}
 
-		// 更新离线状态和相关数据
-		s.db.Model(server).Updates(map[string]interface{}{
-			"status":          "offline",
-			"last_checked":    &stat.Timestamp,
-			"players_online":  0,
-			"max_players":     0,
-			"anonymous_count": 0,
-			"ping":            -1,
-		})
+			// 维护窗口外从可访问变为离线时开始停机事件，尚未检查过或暂停后恢复监控的服务器不算停机
+			if !inMaintenance && wasOnline {
+				s.openIncident(server, &stat)
+			}
 
-		// 广播服务器离线状态
-		s.broadcastServerStatus(server.ID, map[string]interface{}{
-			"id":              server.ID,
-			"name":            server.Name,
-			"status":          "offline",
-			"anonymous_count": 0,
-		})
+			// 更新离线状态和相关数据
+			s.db.Model(server).Updates(map[string]interface{}{
+				"status":          status,
+				"last_checked":    &stat.Timestamp,
+				"players_online":  0,
+				"max_players":     0,
+				"anonymous_count": 0,
+				"ping":            -1,
+				"join_status":     "",
+				"join_message":    "",
+			})
+
+			// 广播服务器离线状态
+			s.broadcastServerStatus(server.ID, map[string]interface{}{
+				"id":              server.ID,
+				"name":            server.Name,
+				"status":          status,
+				"anonymous_count": 0,
+			})
+		}
 	}
 
 	// 保存统计数据
 	if err := s.db.Create(&stat).Error; err != nil {
 		log.Printf("Failed to save server stat for %s: %v", server.Name, err)
 	}
+
+	if status != previousStatus {
+		events.Publish(events.ServerStatusChanged, server.ID, map[string]interface{}{
+			"id":               server.ID,
+			"name":             server.Name,
+			"status":           status,
+			"previous_status":  previousStatus,
+			"players_online":   stat.PlayersOnline,
+			"ping":             stat.Ping,
+			"version":          stat.Version,
+			"failure_category": stat.FailureCategory,
+			"failure_message":  stat.FailureMessage,
+		})
+	}
+
+	// 评估告警规则
+	s.alerts.Evaluate(alerting.Check{
+		Server:          server,
+		Stat:            &stat,
+		Status:          status,
+		Reachable:       err == nil && serverInfo != nil,
+		PreviousVersion: previousVersion,
+	})
+}
+
+// probeWithRetry 根据服务器类型选择探测器探测主端点
+// 失败时按服务器配置的重试次数重试，每次尝试单独计算超时
+func (s *Service) probeWithRetry(server *models.Server) (*services.MinecraftServer, error) {
+	prober := services.ProberFor(server.Type)
+	target := services.ServerProbeTarget(server)
+
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(s.ctx, s.CheckTimeout(server))
+		serverInfo, err := prober.Probe(ctx, target)
+		cancel()
+		if err == nil || attempt >= server.RetryCount {
+			return serverInfo, err
+		}
+
+		log.Printf("Check of %s failed (attempt %d of %d), retrying: %v", server.Name, attempt+1, server.RetryCount+1, err)
+		select {
+		case <-s.ctx.Done():
+			return nil, err
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+// markPaused 将暂停监控的服务器标记为已暂停，并结束其玩家会话
+func (s *Service) markPaused(server *models.Server) {
+	if server.Status == statusPaused {
+		return
+	}
+	previousStatus := server.Status
+
+	if isUp(server.Status) {
+		s.playerSessionService.UpdatePlayerSessions(server, []services.PlayerInfo{})
+	}
+	s.db.Model(server).Updates(map[string]interface{}{
+		"status":               statusPaused,
+		"players_online":       0,
+		"max_players":          0,
+		"anonymous_count":      0,
+		"ping":                 -1,
+		"consecutive_failures": 0,
+	})
+	s.broadcastServerStatus(server.ID, map[string]interface{}{
+		"id":              server.ID,
+		"name":            server.Name,
+		"status":          statusPaused,
+		"anonymous_count": 0,
+	})
+	log.Printf("Monitoring of %s is paused", server.Name)
+	events.Publish(events.ServerStatusChanged, server.ID, map[string]interface{}{
+		"id":              server.ID,
+		"name":            server.Name,
+		"status":          statusPaused,
+		"previous_status": previousStatus,
+	})
+
+	// 暂停期间不再检查，恢复该服务器上触发中的告警
+	s.alerts.Evaluate(alerting.Check{
+		Server: server,
+		Stat:   &models.ServerStat{ServerID: server.ID, Ping: -1, Timestamp: time.Now()},
+		Status: statusPaused,
+	})
+}
+
+// inMaintenance 判断服务器当前是否处于维护窗口
+func (s *Service) inMaintenance(server *models.Server, now time.Time) bool {
+	var windows []models.MaintenanceWindow
+	if err := s.db.Where("server_id = ?", server.ID).Find(&windows).Error; err != nil {
+		log.Printf("Failed to fetch maintenance windows for %s: %v", server.Name, err)
+		return false
+	}
+	return maintenance.Active(windows, now) != nil
+}
+
+// failureThreshold 返回判定服务器离线所需的连续失败次数，未设置时使用全局配置
+func (s *Service) failureThreshold(server *models.Server) int {
+	if server.FailureThreshold > 0 {
+		return server.FailureThreshold
+	}
+	return s.config.FailureThreshold
+}
+
+// checkInterval 返回服务器的检查间隔，未设置时使用全局监控间隔
+func (s *Service) checkInterval(seconds int) time.Duration {
+	if seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return s.config.MonitorInterval
+}
+
+// CheckTimeout 返回服务器单次探测的超时时间，未设置时使用全局Ping超时
+func (s *Service) CheckTimeout(server *models.Server) time.Duration {
+	if server.CheckTimeout > 0 {
+		return time.Duration(server.CheckTimeout) * time.Second
+	}
+	return s.config.PingTimeout
+}
+
+// updateModInfo 保存模组服务器信息，模组列表变化时记录服务器事件
+func (s *Service) updateModInfo(server *models.Server, serverInfo *services.MinecraftServer) {
+	updates := map[string]interface{}{
+		"enforces_secure_chat":  serverInfo.EnforcesSecureChat,
+		"prevents_chat_reports": serverInfo.PreventsChatReports,
+	}
+
+	modInfo := serverInfo.ModInfo
+	hash := modInfo.Hash()
+	if hash != server.ModsHash {
+		var loader string
+		var modsData json.RawMessage
+		var newMods []services.ModEntry
+		if modInfo != nil {
+			loader = modInfo.Loader
+			newMods = modInfo.Mods
+			modsData, _ = json.Marshal(modInfo)
+		}
+		updates["mod_loader"] = loader
+		updates["mods"] = modsData
+		updates["mods_hash"] = hash
+
+		// 首次获取到模组列表时不视为变化
+		if server.ModsHash != "" {
+			var oldInfo services.ModInfo
+			if len(server.Mods) > 0 {
+				json.Unmarshal(server.Mods, &oldInfo)
+			}
+			s.recordModsChanged(server, services.DiffMods(oldInfo.Mods, newMods))
+		}
+	}
+
+	if err := s.db.Model(server).Updates(updates).Error; err != nil {
+		log.Printf("Failed to update mod info for %s: %v", server.Name, err)
+	}
+}
+
+// recordModsChanged 记录模组列表变化事件
+func (s *Service) recordModsChanged(server *models.Server, diff services.ModsDiff) {
+	data, _ := json.Marshal(diff)
+	event := models.ServerEvent{
+		ServerID:  server.ID,
+		EventType: "mods_changed",
+		Message: fmt.Sprintf("模组列表变化: 新增%d个, 移除%d个, 更新%d个",
+			len(diff.Added), len(diff.Removed), len(diff.Updated)),
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+
+	if err := s.db.Create(&event).Error; err != nil {
+		log.Printf("Failed to record mods change for %s: %v", server.Name, err)
+		return
+	}
+	log.Printf("Mod list changed for %s: %s", server.Name, event.Message)
+}
+
+// resolvePlayerList 根据服务器配置的玩家列表来源获取当前在线玩家
+// 返回false表示本次无法获取可信的玩家列表，调用方应保持现有会话不变
+func (s *Service) resolvePlayerList(server *models.Server, serverInfo *services.MinecraftServer) ([]services.PlayerInfo, bool) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.CheckTimeout(server))
+	defer cancel()
+
+	switch server.PlayerSource {
+	case "query":
+		host, port := server.Address, server.QueryPort
+		if port == 0 {
+			host, port, _ = services.ResolveSRV(ctx, server.Address, server.Port)
+		}
+
+		result, err := services.QueryServer(ctx, host, port)
+		if err != nil {
+			log.Printf("Failed to query player list for %s: %v", server.Name, err)
+			return nil, false
+		}
+		return result.PlayerInfos(), true
+	case "rcon":
+		password, err := auth.DecryptSecret(server.RconPassword, s.config.EncryptionKey)
+		if err != nil {
+			log.Printf("Failed to decrypt RCON password for %s: %v", server.Name, err)
+			return nil, false
+		}
+
+		port := server.RconPort
+		if port == 0 {
+			port = 25575
+		}
+		host, _, _ := services.ResolveSRV(ctx, server.Address, server.Port)
+
+		players, err := services.RconPlayerList(ctx, host, port, password)
+		if err != nil {
+			log.Printf("Failed to fetch player list via RCON for %s: %v", server.Name, err)
+			return nil, false
+		}
+		return players, true
+	default:
+		return serverInfo.Players.Sample, true
+	}
+}
+
+// maxFailureMessageLength 保存的错误信息最大长度
+const maxFailureMessageLength = 500
+
+// truncateFailureMessage 截断过长的错误信息，避免异常响应内容占用过多存储
+func truncateFailureMessage(message string) string {
+	runes := []rune(message)
+	if len(runes) <= maxFailureMessageLength {
+		return message
+	}
+	return string(runes[:maxFailureMessageLength]) + "..."
+}
+
+// checkJoin 对开启了加入检查的Java版服务器进行登录握手，返回加入状态及被拒绝的原因
+// 未开启检查或检查本身失败时返回空状态
+func (s *Service) checkJoin(server *models.Server, serverInfo *services.MinecraftServer) (string, string) {
+	if !server.LoginCheck || serverInfo.ServerType != services.JavaEdition {
+		return "", ""
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.CheckTimeout(server))
+	defer cancel()
+
+	result, err := services.LoginCheck(ctx, services.ServerProbeTarget(server), serverInfo.Version.Protocol, server.LoginUsername)
+	if err != nil {
+		log.Printf("Login check for %s failed: %v", server.Name, err)
+		return "", ""
+	}
+	return result.Status, result.Reason.PlainText()
 }
 
 // broadcastServerStatus 广播服务器状态更新
@@ -241,17 +745,10 @@ func (s *Service) broadcastServerStatus(serverID uint, data map[string]interface
 	websocket.BroadcastServerStatus(serverID, data)
 }
 
-// extractDescriptionText 从Description结构体中提取文本
-func extractDescriptionText(desc services.Description) string {
-	if desc.Text != "" {
-		return desc.Text
-	}
-	if len(desc.Extra) > 0 {
-		result := ""
-		for _, extra := range desc.Extra {
-			result += extra.Text
-		}
-		return result
+// extractDescriptionText 从MOTD聊天组件中提取纯文本
+func extractDescriptionText(desc services.ChatComponent) string {
+	if text := desc.PlainText(); text != "" {
+		return text
 	}
 	return "Minecraft Server"
 }
@@ -266,4 +763,11 @@ func (s *Service) cleanupOldStats() {
 	} else if result.RowsAffected > 0 {
 		log.Printf("Cleaned up %d old stat records", result.RowsAffected)
 	}
+
+	result = s.db.Where("timestamp < ?", cutoff).Delete(&models.AgentResult{})
+	if result.Error != nil {
+		log.Printf("Failed to cleanup old agent results: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("Cleaned up %d old agent results", result.RowsAffected)
+	}
 }
\ No newline at end of file
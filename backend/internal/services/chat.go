@@ -0,0 +1,473 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ChatComponent Minecraft聊天组件 (用于MOTD、断开连接原因等)
+type ChatComponent struct {
+	Text          string          `json:"text,omitempty"`
+	Translate     string          `json:"translate,omitempty"`
+	With          []ChatComponent `json:"with,omitempty"`
+	Fallback      string          `json:"fallback,omitempty"`
+	Keybind       string          `json:"keybind,omitempty"`
+	Color         string          `json:"color,omitempty"`
+	Bold          *bool           `json:"bold,omitempty"`
+	Italic        *bool           `json:"italic,omitempty"`
+	Underlined    *bool           `json:"underlined,omitempty"`
+	Strikethrough *bool           `json:"strikethrough,omitempty"`
+	Obfuscated    *bool           `json:"obfuscated,omitempty"`
+	Extra         []ChatComponent `json:"extra,omitempty"`
+}
+
+// chatStyle 经过继承计算后的文本样式
+type chatStyle struct {
+	Color         string
+	Bold          bool
+	Italic        bool
+	Underlined    bool
+	Strikethrough bool
+	Obfuscated    bool
+}
+
+// chatSegment 样式一致的一段文本
+type chatSegment struct {
+	Text  string
+	Style chatStyle
+}
+
+// chatColors 命名颜色对应的RGB值
+var chatColors = map[string]string{
+	"black":        "#000000",
+	"dark_blue":    "#0000AA",
+	"dark_green":   "#00AA00",
+	"dark_aqua":    "#00AAAA",
+	"dark_red":     "#AA0000",
+	"dark_purple":  "#AA00AA",
+	"gold":         "#FFAA00",
+	"gray":         "#AAAAAA",
+	"dark_gray":    "#555555",
+	"blue":         "#5555FF",
+	"green":        "#55FF55",
+	"aqua":         "#55FFFF",
+	"red":          "#FF5555",
+	"light_purple": "#FF55FF",
+	"yellow":       "#FFFF55",
+	"white":        "#FFFFFF",
+}
+
+// legacyColorCodes §颜色代码对应的命名颜色
+var legacyColorCodes = map[rune]string{
+	'0': "black",
+	'1': "dark_blue",
+	'2': "dark_green",
+	'3': "dark_aqua",
+	'4': "dark_red",
+	'5': "dark_purple",
+	'6': "gold",
+	'7': "gray",
+	'8': "dark_gray",
+	'9': "blue",
+	'a': "green",
+	'b': "aqua",
+	'c': "red",
+	'd': "light_purple",
+	'e': "yellow",
+	'f': "white",
+}
+
+// chatTranslations 常见翻译键的英文文本，未收录的键按原版客户端行为直接显示键名
+var chatTranslations = map[string]string{
+	"chat.type.text":                             "<%s> %s",
+	"chat.type.announcement":                     "[%s] %s",
+	"disconnect.genericReason":                   "%s",
+	"disconnect.disconnected":                    "Disconnected by Server",
+	"disconnect.kicked":                          "Was kicked from the game",
+	"multiplayer.disconnect.banned":              "You are banned from this server",
+	"multiplayer.disconnect.banned.reason":       "You are banned from this server.\nReason: %s",
+	"multiplayer.disconnect.kicked":              "Kicked by an operator",
+	"multiplayer.disconnect.not_whitelisted":     "You are not white-listed on this server!",
+	"multiplayer.disconnect.server_full":         "Server is full!",
+	"multiplayer.disconnect.server_shutdown":     "Server closed",
+	"multiplayer.disconnect.outdated_client":     "Incompatible client! Please use %s",
+	"multiplayer.disconnect.incompatible":        "Incompatible client! Please use %s",
+	"multiplayer.disconnect.unverified_username": "Failed to verify username!",
+	"multiplayer.disconnect.name_taken":          "That name is already taken",
+	"multiplayer.disconnect.duplicate_login":     "You logged in from another location",
+}
+
+// translatePlaceholder 匹配 %s 及 %1$s 形式的占位符
+var translatePlaceholder = regexp.MustCompile(`%(?:(\d+)\$)?s|%%`)
+
+// hexColorPattern 合法的十六进制颜色
+var hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// UnmarshalJSON 自定义JSON解析，聊天组件可能是字符串、数组、数字或对象
+func (c *ChatComponent) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	switch data[0] {
+	case '"':
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		*c = ParseLegacyText(str)
+		return nil
+	case '[':
+		// 数组中第一个元素为父组件，其余元素作为其子组件
+		var list []ChatComponent
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+		*c = ChatComponent{}
+		if len(list) > 0 {
+			*c = list[0]
+			c.Extra = append(c.Extra, list[1:]...)
+		}
+		return nil
+	case '{':
+		type Alias ChatComponent
+		aux := &struct {
+			Text interface{} `json:"text,omitempty"`
+			*Alias
+		}{
+			Alias: (*Alias)(c),
+		}
+		if err := json.Unmarshal(data, aux); err != nil {
+			return err
+		}
+		if aux.Text != nil {
+			c.Text = fmt.Sprint(aux.Text)
+		}
+
+		// 文本中仍可能包含§格式代码，将其展开为子组件
+		if strings.ContainsRune(c.Text, '§') {
+			legacy := ParseLegacyText(c.Text)
+			c.Text = legacy.Text
+			c.Extra = append(legacy.Extra, c.Extra...)
+		}
+		return nil
+	default:
+		// 数字或布尔值直接作为文本
+		*c = ChatComponent{Text: string(data)}
+		return nil
+	}
+}
+
+// ParseLegacyText 将包含§格式代码的文本解析为聊天组件
+func ParseLegacyText(text string) ChatComponent {
+	if !strings.ContainsRune(text, '§') {
+		return ChatComponent{Text: text}
+	}
+
+	root := ChatComponent{}
+	var style chatStyle
+	var current strings.Builder
+
+	flush := func() {
+		if current.Len() == 0 {
+			return
+		}
+		root.Extra = append(root.Extra, style.component(current.String()))
+		current.Reset()
+	}
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '§' || i+1 >= len(runes) {
+			current.WriteRune(runes[i])
+			continue
+		}
+
+		code := toLowerRune(runes[i+1])
+
+		// BungeeCord 十六进制颜色格式: §x§R§R§G§G§B§B
+		if code == 'x' && i+13 < len(runes) {
+			if hex, ok := parseLegacyHex(runes[i+2 : i+14]); ok {
+				flush()
+				style = chatStyle{Color: hex}
+				i += 13
+				continue
+			}
+		}
+
+		i++
+		if color, ok := legacyColorCodes[code]; ok {
+			flush()
+			// 颜色代码会重置之前的格式
+			style = chatStyle{Color: color}
+			continue
+		}
+
+		switch code {
+		case 'k':
+			flush()
+			style.Obfuscated = true
+		case 'l':
+			flush()
+			style.Bold = true
+		case 'm':
+			flush()
+			style.Strikethrough = true
+		case 'n':
+			flush()
+			style.Underlined = true
+		case 'o':
+			flush()
+			style.Italic = true
+		case 'r':
+			flush()
+			style = chatStyle{}
+		default:
+			// 未知代码按原版行为忽略
+		}
+	}
+	flush()
+
+	return root
+}
+
+// PlainText 返回去除所有格式的纯文本
+func (c ChatComponent) PlainText() string {
+	var result strings.Builder
+	for _, segment := range c.segments() {
+		result.WriteString(segment.Text)
+	}
+	return result.String()
+}
+
+// HTML 返回经过转义的HTML，样式以内联style表示，混淆文本带有 mc-obfuscated 类
+func (c ChatComponent) HTML() string {
+	var result strings.Builder
+	for _, segment := range c.segments() {
+		var styles []string
+		if color := resolveChatColor(segment.Style.Color); color != "" {
+			styles = append(styles, "color:"+color)
+		}
+		if segment.Style.Bold {
+			styles = append(styles, "font-weight:bold")
+		}
+		if segment.Style.Italic {
+			styles = append(styles, "font-style:italic")
+		}
+
+		var decorations []string
+		if segment.Style.Underlined {
+			decorations = append(decorations, "underline")
+		}
+		if segment.Style.Strikethrough {
+			decorations = append(decorations, "line-through")
+		}
+		if len(decorations) > 0 {
+			styles = append(styles, "text-decoration:"+strings.Join(decorations, " "))
+		}
+
+		text := strings.ReplaceAll(html.EscapeString(segment.Text), "\n", "<br>")
+		if len(styles) == 0 && !segment.Style.Obfuscated {
+			result.WriteString(text)
+			continue
+		}
+
+		result.WriteString("<span")
+		if segment.Style.Obfuscated {
+			result.WriteString(` class="mc-obfuscated"`)
+		}
+		if len(styles) > 0 {
+			result.WriteString(` style="` + strings.Join(styles, ";") + `"`)
+		}
+		result.WriteString(">" + text + "</span>")
+	}
+	return result.String()
+}
+
+// ANSI 返回带有ANSI转义序列的终端文本 (使用24位真彩色)
+func (c ChatComponent) ANSI() string {
+	var result strings.Builder
+	for _, segment := range c.segments() {
+		var codes []string
+		if color := resolveChatColor(segment.Style.Color); color != "" {
+			r, _ := strconv.ParseUint(color[1:3], 16, 8)
+			g, _ := strconv.ParseUint(color[3:5], 16, 8)
+			b, _ := strconv.ParseUint(color[5:7], 16, 8)
+			codes = append(codes, fmt.Sprintf("38;2;%d;%d;%d", r, g, b))
+		}
+		if segment.Style.Bold {
+			codes = append(codes, "1")
+		}
+		if segment.Style.Italic {
+			codes = append(codes, "3")
+		}
+		if segment.Style.Underlined {
+			codes = append(codes, "4")
+		}
+		if segment.Style.Obfuscated {
+			codes = append(codes, "5")
+		}
+		if segment.Style.Strikethrough {
+			codes = append(codes, "9")
+		}
+
+		if len(codes) == 0 {
+			result.WriteString(segment.Text)
+			continue
+		}
+		result.WriteString("\x1b[" + strings.Join(codes, ";") + "m" + segment.Text + "\x1b[0m")
+	}
+	return result.String()
+}
+
+// segments 按样式继承规则将组件树展开为文本片段
+func (c ChatComponent) segments() []chatSegment {
+	var segments []chatSegment
+	c.appendSegments(chatStyle{}, &segments)
+	return segments
+}
+
+// appendSegments 递归展开组件及其子组件
+func (c ChatComponent) appendSegments(parent chatStyle, segments *[]chatSegment) {
+	style := c.inherit(parent)
+
+	switch {
+	case c.Translate != "":
+		c.appendTranslation(style, segments)
+	case c.Keybind != "":
+		*segments = append(*segments, chatSegment{Text: c.Keybind, Style: style})
+	case c.Text != "":
+		*segments = append(*segments, chatSegment{Text: c.Text, Style: style})
+	}
+
+	for _, extra := range c.Extra {
+		extra.appendSegments(style, segments)
+	}
+}
+
+// appendTranslation 展开translate组件，参数按位置或顺序替换占位符
+func (c ChatComponent) appendTranslation(style chatStyle, segments *[]chatSegment) {
+	template, ok := chatTranslations[c.Translate]
+	if !ok {
+		template = c.Translate
+		if c.Fallback != "" {
+			template = c.Fallback
+		}
+	}
+
+	last := 0
+	next := 0
+	for _, match := range translatePlaceholder.FindAllStringSubmatchIndex(template, -1) {
+		if match[0] > last {
+			*segments = append(*segments, chatSegment{Text: template[last:match[0]], Style: style})
+		}
+		last = match[1]
+
+		if template[match[0]:match[1]] == "%%" {
+			*segments = append(*segments, chatSegment{Text: "%", Style: style})
+			continue
+		}
+
+		index := next
+		if match[2] >= 0 {
+			position, _ := strconv.Atoi(template[match[2]:match[3]])
+			index = position - 1
+		} else {
+			next++
+		}
+		if index >= 0 && index < len(c.With) {
+			c.With[index].appendSegments(style, segments)
+		}
+	}
+	if last < len(template) {
+		*segments = append(*segments, chatSegment{Text: template[last:], Style: style})
+	}
+}
+
+// inherit 计算组件在父样式基础上的实际样式
+func (c ChatComponent) inherit(parent chatStyle) chatStyle {
+	style := parent
+	if c.Color != "" {
+		style.Color = c.Color
+	}
+	if c.Bold != nil {
+		style.Bold = *c.Bold
+	}
+	if c.Italic != nil {
+		style.Italic = *c.Italic
+	}
+	if c.Underlined != nil {
+		style.Underlined = *c.Underlined
+	}
+	if c.Strikethrough != nil {
+		style.Strikethrough = *c.Strikethrough
+	}
+	if c.Obfuscated != nil {
+		style.Obfuscated = *c.Obfuscated
+	}
+	return style
+}
+
+// component 以当前样式创建文本组件
+func (s chatStyle) component(text string) ChatComponent {
+	component := ChatComponent{Text: text, Color: s.Color}
+	if s.Bold {
+		component.Bold = boolPtr(true)
+	}
+	if s.Italic {
+		component.Italic = boolPtr(true)
+	}
+	if s.Underlined {
+		component.Underlined = boolPtr(true)
+	}
+	if s.Strikethrough {
+		component.Strikethrough = boolPtr(true)
+	}
+	if s.Obfuscated {
+		component.Obfuscated = boolPtr(true)
+	}
+	return component
+}
+
+// resolveChatColor 将颜色名或十六进制颜色转换为 #RRGGBB，非法值返回空字符串
+func resolveChatColor(color string) string {
+	if hex, ok := chatColors[color]; ok {
+		return hex
+	}
+	if hexColorPattern.MatchString(color) {
+		return strings.ToUpper(color)
+	}
+	return ""
+}
+
+// parseLegacyHex 解析 §R§R§G§G§B§B 形式的十六进制颜色
+func parseLegacyHex(runes []rune) (string, bool) {
+	var hex strings.Builder
+	hex.WriteByte('#')
+	for i := 0; i < len(runes); i += 2 {
+		if runes[i] != '§' || !strings.ContainsRune("0123456789abcdefABCDEF", runes[i+1]) {
+			return "", false
+		}
+		hex.WriteRune(runes[i+1])
+	}
+	return strings.ToUpper(hex.String()), true
+}
+
+// toLowerRune 将格式代码转换为小写
+func toLowerRune(r rune) rune {
+	if r >= 'A' && r <= 'Z' {
+		return r + ('a' - 'A')
+	}
+	return r
+}
+
+// boolPtr 返回布尔值指针
+func boolPtr(b bool) *bool {
+	return &b
+}
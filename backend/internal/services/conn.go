@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// defaultProbeTimeout 调用方未在ctx中设置截止时间时使用的超时
+const defaultProbeTimeout = 10 * time.Second
+
+// ctxConn ctx取消时自动关闭的连接
+type ctxConn struct {
+	net.Conn
+	stop func() bool
+}
+
+// Close 关闭连接并解除与ctx的关联
+func (c *ctxConn) Close() error {
+	c.stop()
+	return c.Conn.Close()
+}
+
+// dialContext 建立连接并将ctx的截止时间设为读写超时
+// ctx被取消时连接会被立即关闭，从而中断所有阻塞中的读写
+func dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultProbeTimeout)
+	}
+	conn.SetDeadline(deadline)
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	return &ctxConn{Conn: conn, stop: stop}, nil
+}
+
+// contextError ctx已结束时返回带有原因的错误，用于替代连接被关闭产生的底层错误
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("探测已取消: %w", ctxErr)
+	}
+	return err
+}
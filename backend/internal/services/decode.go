@@ -0,0 +1,105 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// 响应大小限制，防止异常或恶意服务器让监控分配过大的内存
+const (
+	maxStatusPacketLength = 2 << 20               // Java版状态响应包的最大长度
+	maxStatusJSONLength   = maxStatusPacketLength // 状态JSON的最大长度
+	maxBedrockPongLength  = 2048                  // 基岩版Unconnected Pong的最大长度
+	maxVarIntBytes        = 5                     // VarInt最多占用的字节数
+)
+
+// 解析错误的具体原因，可通过 errors.Is 判断
+var (
+	ErrPacketTooLarge = errors.New("数据超出大小限制")
+	ErrTruncated      = errors.New("数据不完整")
+	ErrMalformed      = errors.New("数据格式无效")
+)
+
+// ParseError 服务器响应无法解析
+type ParseError struct {
+	Protocol string // 协议: "java", "bedrock", "legacy", "query", "login"
+	Field    string // 出错的字段
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("解析%s响应失败 (%s): %v", e.Protocol, e.Field, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parseError 创建解析错误，detail 非空时附加到原因之后
+func parseError(protocol, field string, err error, detail string) *ParseError {
+	if detail != "" {
+		err = fmt.Errorf("%w: %s", err, detail)
+	}
+	return &ParseError{Protocol: protocol, Field: field, Err: err}
+}
+
+// readPacket 读取一个带VarInt长度前缀的数据包，返回不含长度前缀的内容
+// 长度在分配内存前校验，超过 maxLength 时返回 ErrPacketTooLarge
+func readPacket(r io.Reader, protocol string, maxLength int) ([]byte, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if length <= 0 {
+		return nil, parseError(protocol, "packet length", ErrMalformed, fmt.Sprint(length))
+	}
+	if int(length) > maxLength {
+		return nil, parseError(protocol, "packet length", ErrPacketTooLarge, fmt.Sprintf("%d > %d", length, maxLength))
+	}
+
+	packet := make([]byte, length)
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return nil, err
+	}
+	return packet, nil
+}
+
+// decodeJavaStatusPacket 解码Java版状态响应包 (不含长度前缀)
+// 纯函数，不进行任何网络操作，任意输入均不会panic
+func decodeJavaStatusPacket(packet []byte) (*JavaServerStatus, error) {
+	reader := bytes.NewReader(packet)
+
+	packetID, err := readVarIntFromReader(reader)
+	if err != nil {
+		return nil, parseError("java", "packet id", ErrTruncated, err.Error())
+	}
+	if packetID != 0x00 {
+		return nil, parseError("java", "packet id", ErrMalformed, fmt.Sprint(packetID))
+	}
+
+	jsonLength, err := readVarIntFromReader(reader)
+	if err != nil {
+		return nil, parseError("java", "json length", ErrTruncated, err.Error())
+	}
+	if jsonLength < 0 {
+		return nil, parseError("java", "json length", ErrMalformed, fmt.Sprint(jsonLength))
+	}
+	if int(jsonLength) > maxStatusJSONLength {
+		return nil, parseError("java", "json length", ErrPacketTooLarge, fmt.Sprint(jsonLength))
+	}
+	if int(jsonLength) > reader.Len() {
+		return nil, parseError("java", "json", ErrTruncated, fmt.Sprintf("需要 %d 字节，实际 %d 字节", jsonLength, reader.Len()))
+	}
+
+	jsonData := make([]byte, jsonLength)
+	reader.Read(jsonData)
+
+	var status JavaServerStatus
+	if err := json.Unmarshal(jsonData, &status); err != nil {
+		return nil, parseError("java", "json", ErrMalformed, fmt.Sprintf("%v (前200字节: %s)", err, jsonData[:minInt(200, len(jsonData))]))
+	}
+	return &status, nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+// 种子语料位于 testdata/fuzz/<FuzzName>/ 下，包含原版、Paper、Forge及基岩版服务器的响应
+// 运行: go test -run=^$ -fuzz=FuzzDecodeJavaStatusPacket ./internal/services
+
+// FuzzDecodeJavaStatusPacket 任意输入都不应panic，失败时必须返回 *ParseError
+func FuzzDecodeJavaStatusPacket(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0x00})
+	f.Add([]byte{0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0x0F})
+	f.Add([]byte{0x00, 0x02, '{', '}'})
+
+	f.Fuzz(func(t *testing.T, packet []byte) {
+		status, err := decodeJavaStatusPacket(packet)
+		if err != nil {
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected *ParseError, got %T: %v", err, err)
+			}
+			if status != nil {
+				t.Fatalf("status should be nil on error")
+			}
+			return
+		}
+		if status == nil {
+			t.Fatalf("status is nil without error")
+		}
+
+		// 后续处理同样不应panic
+		extractModInfo(status)
+		status.Description.PlainText()
+		status.Description.HTML()
+		if status.Favicon != "" {
+			DecodeFavicon(status.Favicon)
+		}
+	})
+}
+
+// FuzzParseBedrockResponse 任意输入都不应panic，失败时必须返回 *ParseError
+func FuzzParseBedrockResponse(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0x1C})
+	f.Add(make([]byte, maxBedrockPongLength+1))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		status, err := parseBedrockResponse(data)
+		if err != nil {
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected *ParseError, got %T: %v", err, err)
+			}
+			if status != nil {
+				t.Fatalf("status should be nil on error")
+			}
+			return
+		}
+		if status == nil {
+			t.Fatalf("status is nil without error")
+		}
+		if len(data) > maxBedrockPongLength {
+			t.Fatalf("accepted %d bytes, limit is %d", len(data), maxBedrockPongLength)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+)
+
+// FailureCategory 探测失败的原因分类
+type FailureCategory string
+
+const (
+	FailureNone            FailureCategory = ""
+	FailureDNS             FailureCategory = "dns"                // 主机名解析失败
+	FailureSRV             FailureCategory = "srv"                // SRV记录查询失败
+	FailureRefused         FailureCategory = "connection_refused" // 无法建立连接 (拒绝连接、主机不可达等)
+	FailureTimeout         FailureCategory = "timeout"            // 连接或等待响应超时
+	FailureProtocol        FailureCategory = "protocol_error"     // 连接建立后通信中断或不符合协议
+	FailureInvalidResponse FailureCategory = "invalid_response"   // 收到的响应无法解析
+	FailureUnknown         FailureCategory = "unknown"
+)
+
+// SRVError SRV记录查询失败 (不含记录不存在的情况)
+type SRVError struct {
+	Err error
+}
+
+func (e *SRVError) Error() string {
+	return "SRV记录查询失败: " + e.Err.Error()
+}
+
+func (e *SRVError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyFailure 根据探测返回的错误判断失败类型
+func ClassifyFailure(err error) FailureCategory {
+	if err == nil {
+		return FailureNone
+	}
+
+	var srvErr *SRVError
+	if errors.As(err, &srvErr) {
+		return FailureSRV
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return FailureDNS
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return FailureTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return FailureTimeout
+	}
+
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return FailureInvalidResponse
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return FailureRefused
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return FailureRefused
+	}
+
+	// 连接已建立但通信失败 (连接被重置、提前关闭、收到意外的数据包等)
+	if opErr != nil || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return FailureProtocol
+	}
+	return FailureUnknown
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"etamonitor/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	faviconDataURIPrefix = "data:image/png;base64,"
+
+	// 原版图标为64x64的PNG，这里给予足够余量
+	maxFaviconSize = 1 << 20
+)
+
+// pngSignature PNG文件头
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+
+// DecodeFavicon 解码状态响应中的base64 PNG图标，返回图片数据及其SHA-256哈希
+func DecodeFavicon(dataURI string) ([]byte, string, error) {
+	if !strings.HasPrefix(dataURI, faviconDataURIPrefix) {
+		return nil, "", fmt.Errorf("不支持的图标格式")
+	}
+
+	// 部分服务端会在base64中插入换行
+	encoded := strings.NewReplacer("\n", "", "\r", "").Replace(dataURI[len(faviconDataURIPrefix):])
+	if base64.StdEncoding.DecodedLen(len(encoded)) > maxFaviconSize {
+		return nil, "", fmt.Errorf("图标过大")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, "", fmt.Errorf("解码图标失败: %v", err)
+	}
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, "", fmt.Errorf("图标不是有效的PNG图片")
+	}
+
+	sum := sha256.Sum256(data)
+	return data, hex.EncodeToString(sum[:]), nil
+}
+
+// FaviconService 服务器图标存储服务
+type FaviconService struct {
+	db *gorm.DB
+}
+
+// NewFaviconService 创建服务器图标存储服务
+func NewFaviconService(db *gorm.DB) *FaviconService {
+	return &FaviconService{db: db}
+}
+
+// Store 保存服务器当前的图标，图标变化时记录变更历史
+// 返回值表示图标是否发生了变化
+func (f *FaviconService) Store(server *models.Server, dataURI string) (bool, error) {
+	if dataURI == "" {
+		return false, nil
+	}
+
+	data, hash, err := DecodeFavicon(dataURI)
+	if err != nil {
+		return false, err
+	}
+	if hash == server.FaviconHash {
+		return false, nil
+	}
+
+	now := time.Now()
+	err = f.db.Transaction(func(tx *gorm.DB) error {
+		// 相同内容的图标只保存一份
+		var existing models.Favicon
+		if err := tx.Where("hash = ?", hash).First(&existing).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			favicon := models.Favicon{Hash: hash, Data: data, Size: len(data), CreatedAt: now}
+			if err := tx.Create(&favicon).Error; err != nil {
+				return err
+			}
+		}
+
+		change := models.FaviconChange{ServerID: server.ID, Hash: hash, ChangedAt: now}
+		if err := tx.Create(&change).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(server).Update("favicon_hash", hash).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("保存图标失败: %v", err)
+	}
+
+	log.Printf("服务器 %s 图标已更新: %s", server.Name, hash[:12])
+	return true, nil
+}
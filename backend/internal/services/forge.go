@@ -0,0 +1,297 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// forgeIgnoreServerOnly 客户端无需安装的模组在d字段中不携带版本号
+const forgeIgnoreServerOnly = "<not required for client>"
+
+// ForgeData Forge 1.13+ 在状态响应中附带的 forgeData 字段
+type ForgeData struct {
+	Channels []struct {
+		Res      string `json:"res"`
+		Version  string `json:"version"`
+		Required bool   `json:"required"`
+	} `json:"channels"`
+	Mods []struct {
+		ModID     string `json:"modId"`
+		ModMarker string `json:"modmarker"`
+	} `json:"mods"`
+	FMLNetworkVersion int    `json:"fmlNetworkVersion"`
+	D                 string `json:"d,omitempty"`
+	Truncated         bool   `json:"truncated,omitempty"`
+}
+
+// LegacyModInfo Forge 1.7-1.12 在状态响应中附带的 modinfo 字段
+type LegacyModInfo struct {
+	Type    string `json:"type"`
+	ModList []struct {
+		ModID   string `json:"modid"`
+		Version string `json:"version"`
+	} `json:"modList"`
+}
+
+// ModInfo 统一的模组服务器信息
+type ModInfo struct {
+	Loader            string       `json:"loader"` // "forge", "neoforge", "fml" 等
+	FMLNetworkVersion int          `json:"fml_network_version,omitempty"`
+	Mods              []ModEntry   `json:"mods"`
+	Channels          []ModChannel `json:"channels,omitempty"`
+	Truncated         bool         `json:"truncated,omitempty"`
+}
+
+// ModEntry 单个模组信息
+type ModEntry struct {
+	ID      string `json:"id"`
+	Version string `json:"version,omitempty"`
+}
+
+// ModChannel 模组网络通道信息
+type ModChannel struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Required bool   `json:"required"`
+}
+
+// ModsDiff 两次模组列表之间的差异
+type ModsDiff struct {
+	Added   []ModEntry `json:"added,omitempty"`
+	Removed []ModEntry `json:"removed,omitempty"`
+	Updated []ModEntry `json:"updated,omitempty"`
+}
+
+// Empty 判断是否没有任何差异
+func (d ModsDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Updated) == 0
+}
+
+// Hash 计算模组列表的摘要，用于判断模组列表是否变化
+func (m *ModInfo) Hash() string {
+	if m == nil {
+		return ""
+	}
+
+	entries := make([]string, 0, len(m.Mods))
+	for _, mod := range m.Mods {
+		entries = append(entries, mod.ID+"@"+mod.Version)
+	}
+	sort.Strings(entries)
+
+	sum := sha256.Sum256([]byte(m.Loader + "\n" + strings.Join(entries, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// DiffMods 比较新旧模组列表
+func DiffMods(oldMods, newMods []ModEntry) ModsDiff {
+	oldVersions := make(map[string]string, len(oldMods))
+	for _, mod := range oldMods {
+		oldVersions[mod.ID] = mod.Version
+	}
+
+	var diff ModsDiff
+	newIDs := make(map[string]bool, len(newMods))
+	for _, mod := range newMods {
+		newIDs[mod.ID] = true
+		version, exists := oldVersions[mod.ID]
+		if !exists {
+			diff.Added = append(diff.Added, mod)
+		} else if version != mod.Version {
+			diff.Updated = append(diff.Updated, mod)
+		}
+	}
+	for _, mod := range oldMods {
+		if !newIDs[mod.ID] {
+			diff.Removed = append(diff.Removed, mod)
+		}
+	}
+
+	return diff
+}
+
+// extractModInfo 从Java版状态响应中提取模组信息
+func extractModInfo(status *JavaServerStatus) *ModInfo {
+	isNeoForge := strings.Contains(strings.ToLower(status.Version.Name), "neoforge")
+
+	switch {
+	case status.ForgeData != nil:
+		info := parseForgeData(status.ForgeData)
+		if isNeoForge {
+			info.Loader = "neoforge"
+		}
+		return info
+	case status.ModInfo != nil:
+		info := &ModInfo{Loader: strings.ToLower(status.ModInfo.Type)}
+		for _, mod := range status.ModInfo.ModList {
+			info.Mods = append(info.Mods, ModEntry{ID: mod.ModID, Version: mod.Version})
+		}
+		return info
+	case isNeoForge || (status.IsModded != nil && *status.IsModded):
+		// NeoForge 新版本不再在状态响应中附带模组列表
+		loader := "forge"
+		if isNeoForge {
+			loader = "neoforge"
+		}
+		return &ModInfo{Loader: loader}
+	default:
+		return nil
+	}
+}
+
+// parseForgeData 解析forgeData字段，优先使用压缩的d字段中的完整模组列表
+func parseForgeData(data *ForgeData) *ModInfo {
+	info := &ModInfo{
+		Loader:            "forge",
+		FMLNetworkVersion: data.FMLNetworkVersion,
+		Truncated:         data.Truncated,
+	}
+
+	if data.D != "" {
+		if decoded, err := decodeForgeModList(data.D); err == nil {
+			decoded.Loader = info.Loader
+			decoded.FMLNetworkVersion = info.FMLNetworkVersion
+			return decoded
+		}
+	}
+
+	for _, mod := range data.Mods {
+		info.Mods = append(info.Mods, ModEntry{ID: mod.ModID, Version: mod.ModMarker})
+	}
+	for _, channel := range data.Channels {
+		info.Channels = append(info.Channels, ModChannel{
+			Name:     channel.Res,
+			Version:  channel.Version,
+			Required: channel.Required,
+		})
+	}
+
+	return info
+}
+
+// decodeForgeModList 解码Forge的d字段
+// d字段将二进制数据按每个字符15位的方式编码为字符串，前两个字符保存数据长度
+func decodeForgeModList(encoded string) (*ModInfo, error) {
+	data, err := decodeForgeOptimized(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bytes.NewReader(data)
+	info := &ModInfo{}
+
+	truncated, err := reader.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("读取截断标记失败: %v", err)
+	}
+	info.Truncated = truncated != 0
+
+	var modCount uint16
+	if err := binary.Read(reader, binary.BigEndian, &modCount); err != nil {
+		return nil, fmt.Errorf("读取模组数量失败: %v", err)
+	}
+
+	for i := 0; i < int(modCount); i++ {
+		flag, err := readVarIntFromReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("读取模组标记失败: %v", err)
+		}
+		channelCount := int(uint32(flag) >> 1)
+		ignoreServerOnly := flag&0x01 != 0
+
+		modID, err := readStringFromReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("读取模组ID失败: %v", err)
+		}
+
+		version := forgeIgnoreServerOnly
+		if !ignoreServerOnly {
+			if version, err = readStringFromReader(reader); err != nil {
+				return nil, fmt.Errorf("读取模组版本失败: %v", err)
+			}
+		}
+		info.Mods = append(info.Mods, ModEntry{ID: modID, Version: version})
+
+		for j := 0; j < channelCount; j++ {
+			channel, err := readForgeChannel(reader)
+			if err != nil {
+				return nil, err
+			}
+			channel.Name = modID + ":" + channel.Name
+			info.Channels = append(info.Channels, channel)
+		}
+	}
+
+	nonModChannels, err := readVarIntFromReader(reader)
+	if err != nil {
+		// 被截断的数据可能不包含非模组通道
+		return info, nil
+	}
+	for i := 0; i < int(nonModChannels); i++ {
+		channel, err := readForgeChannel(reader)
+		if err != nil {
+			return nil, err
+		}
+		info.Channels = append(info.Channels, channel)
+	}
+
+	return info, nil
+}
+
+// decodeForgeOptimized 还原d字段中按15位编码的二进制数据
+func decodeForgeOptimized(encoded string) ([]byte, error) {
+	chars := []rune(encoded)
+	if len(chars) < 2 {
+		return nil, fmt.Errorf("d字段长度无效")
+	}
+
+	size := int(chars[0]) | int(chars[1])<<15
+	if size < 0 || size > len(chars)*2 {
+		return nil, fmt.Errorf("d字段声明的长度无效: %d", size)
+	}
+
+	result := make([]byte, 0, size)
+	buffer := uint32(0)
+	bitsInBuf := 0
+	for _, c := range chars[2:] {
+		for bitsInBuf >= 8 {
+			result = append(result, byte(buffer))
+			buffer >>= 8
+			bitsInBuf -= 8
+		}
+		buffer |= (uint32(c) & 0x7FFF) << bitsInBuf
+		bitsInBuf += 15
+	}
+	for len(result) < size && bitsInBuf > 0 {
+		result = append(result, byte(buffer))
+		buffer >>= 8
+		bitsInBuf -= 8
+	}
+
+	if len(result) > size {
+		result = result[:size]
+	}
+	return result, nil
+}
+
+// readForgeChannel 读取一个网络通道 (名称 + 版本 + 是否必需)
+func readForgeChannel(reader *bytes.Reader) (ModChannel, error) {
+	name, err := readStringFromReader(reader)
+	if err != nil {
+		return ModChannel{}, fmt.Errorf("读取通道名称失败: %v", err)
+	}
+	version, err := readStringFromReader(reader)
+	if err != nil {
+		return ModChannel{}, fmt.Errorf("读取通道版本失败: %v", err)
+	}
+	required, err := reader.ReadByte()
+	if err != nil {
+		return ModChannel{}, fmt.Errorf("读取通道必需标记失败: %v", err)
+	}
+	return ModChannel{Name: name, Version: version, Required: required != 0}, nil
+}
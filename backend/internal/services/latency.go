@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Latency 单次探测各阶段的耗时 (毫秒)，-1 表示该阶段未执行或协议不支持
+type Latency struct {
+	DNS     int `json:"dns"`     // SRV记录及主机地址解析
+	Connect int `json:"connect"` // 建立TCP连接，UDP协议为0
+	Status  int `json:"status"`  // 发送状态请求到收到完整响应
+	Ping    int `json:"ping"`    // Java版0x01 ping/pong往返，基岩版为Unconnected Ping往返
+}
+
+// newLatency 创建所有阶段均未测量的耗时记录
+func newLatency() Latency {
+	return Latency{DNS: -1, Connect: -1, Status: -1, Ping: -1}
+}
+
+// RoundTrip 返回最能代表网络往返延迟的耗时: 优先使用ping往返，否则使用状态响应耗时
+func (l Latency) RoundTrip() int {
+	if l.Ping >= 0 {
+		return l.Ping
+	}
+	return l.Status
+}
+
+// millis 将耗时转换为毫秒
+func millis(d time.Duration) int {
+	return int(d.Milliseconds())
+}
+
+// resolveTarget 解析SRV记录和主机地址，返回可直接连接的IP列表及端口
+func resolveTarget(ctx context.Context, host string, port int) ([]string, int, error) {
+	resolvedHost, resolvedPort, srvErr := ResolveSRV(ctx, host, port)
+	if srvErr != nil {
+		resolvedHost, resolvedPort = host, port
+	}
+
+	if ip := net.ParseIP(resolvedHost); ip != nil {
+		return []string{resolvedHost}, resolvedPort, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, resolvedHost)
+	if err != nil {
+		// 主机本身没有地址记录时，SRV查询失败才是无法连接的原因
+		var dnsErr *net.DNSError
+		if srvErr != nil && errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, 0, srvErr
+		}
+		return nil, 0, err
+	}
+	return addrs, resolvedPort, nil
+}
+
+// dialTimed 解析地址并建立连接，分别记录DNS解析与建立连接的耗时
+// 解析到多个地址时依次尝试，直到连接成功
+func dialTimed(ctx context.Context, network, host string, port int, latency *Latency) (net.Conn, error) {
+	start := time.Now()
+	addrs, resolvedPort, err := resolveTarget(ctx, host, port)
+	latency.DNS = millis(time.Since(start))
+	if err != nil {
+		// 与拨号阶段的解析失败保持一致，调用方据此判断无需再尝试其他协议
+		return nil, &net.OpError{Op: "dial", Net: network, Err: err}
+	}
+
+	start = time.Now()
+	var conn net.Conn
+	for _, addr := range addrs {
+		conn, err = dialContext(ctx, network, net.JoinHostPort(addr, strconv.Itoa(resolvedPort)))
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if network == "tcp" {
+		latency.Connect = millis(time.Since(start))
+	} else {
+		latency.Connect = 0
+	}
+	return conn, nil
+}
+
+// Jitter 按RFC 3550的方法平滑计算延迟抖动
+// prevJitter 为上一次的抖动值，prevPing 与 ping 为相邻两次检查的延迟，任一延迟无效时沿用上一次的值
+func Jitter(prevJitter float64, prevPing, ping int) float64 {
+	if prevPing < 0 || ping < 0 {
+		return prevJitter
+	}
+	diff := float64(ping - prevPing)
+	if diff < 0 {
+		diff = -diff
+	}
+	return prevJitter + (diff-prevJitter)/16
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// 旧版协议常量
+const (
+	legacyPingPacketID   = 0xFE
+	legacyKickPacketID   = 0xFF
+	legacyPluginPacketID = 0xFA
+
+	// 1.6 MC|PingHost 中携带的协议版本 (1.6.4)
+	legacyPingHostProtocol = 78
+
+	// 踢出包中字符串的最大长度 (UTF-16字符数)
+	legacyMaxStringLength = 32767
+)
+
+// LegacyServerStatus 旧版(1.6及更早)服务器状态结构
+type LegacyServerStatus struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	Version         string `json:"version"`
+	MOTD            string `json:"motd"`
+	PlayersOnline   int    `json:"players_online"`
+	MaxPlayers      int    `json:"max_players"`
+}
+
+// LegacyServerPing 使用旧版服务器列表ping查询Java版服务器状态
+// 优先使用1.6的MC|PingHost格式，失败时退回到1.4-1.5的0xFE 0x01格式
+func LegacyServerPing(ctx context.Context, target ProbeTarget) (*MinecraftServer, error) {
+	server, err := legacyPing(ctx, target, createLegacyPingHostPacket(target.handshakeHost(), target.Port))
+	if err == nil {
+		return server, nil
+	}
+	if ctx.Err() != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	server, fallbackErr := legacyPing(ctx, target, []byte{legacyPingPacketID, 0x01})
+	if fallbackErr != nil {
+		return nil, contextError(ctx, fmt.Errorf("旧版ping失败: %w; %w", err, fallbackErr))
+	}
+	return server, nil
+}
+
+// legacyPing 发送旧版ping请求并解析踢出包中的状态信息
+func legacyPing(ctx context.Context, target ProbeTarget, request []byte) (*MinecraftServer, error) {
+	latency := newLatency()
+	conn, err := dialTimed(ctx, "tcp", target.Host, target.Port, &latency)
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %w", err)
+	}
+	defer conn.Close()
+
+	if err = writeProxyHeader(conn, target.ProxyProtocol); err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+
+	if _, err = conn.Write(request); err != nil {
+		return nil, fmt.Errorf("发送ping请求失败: %w", err)
+	}
+
+	// 响应格式: 0xFF + 字符串长度(short) + UTF-16BE字符串
+	header := make([]byte, 3)
+	if _, err = io.ReadFull(conn, header); err != nil {
+		return nil, fmt.Errorf("读取响应头失败: %w", err)
+	}
+	if header[0] != legacyKickPacketID {
+		return nil, parseError("legacy", "packet id", ErrMalformed, fmt.Sprint(header[0]))
+	}
+
+	length := int(binary.BigEndian.Uint16(header[1:3]))
+	if length == 0 {
+		return nil, parseError("legacy", "length", ErrMalformed, "0")
+	}
+	if length > legacyMaxStringLength {
+		return nil, parseError("legacy", "length", ErrPacketTooLarge, fmt.Sprint(length))
+	}
+
+	data := make([]byte, length*2)
+	if _, err = io.ReadFull(conn, data); err != nil {
+		return nil, fmt.Errorf("读取响应数据失败: %w", err)
+	}
+
+	// 旧版协议没有独立的ping包，只记录状态响应耗时
+	latency.Status = millis(time.Since(startTime))
+
+	status, err := parseLegacyResponse(decodeUTF16BE(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return &MinecraftServer{
+		ServerType: JavaEdition,
+		Version: VersionInfo{
+			Name:     status.Version,
+			Protocol: status.ProtocolVersion,
+		},
+		Players: Players{
+			Online: status.PlayersOnline,
+			Max:    status.MaxPlayers,
+		},
+		Release:     ResolveRelease(ReleaseEditionLegacy, status.ProtocolVersion, status.Version),
+		Description: ParseLegacyText(status.MOTD),
+		Ping:        latency.RoundTrip(),
+		Latency:     latency,
+		Online:      true,
+		RawData:     status,
+	}, nil
+}
+
+// parseLegacyResponse 解析旧版踢出包中的状态字符串
+// 1.4+ 格式: "§1\x00协议版本\x00版本名\x00MOTD\x00在线人数\x00最大人数"
+// Beta 1.8-1.3 格式: "MOTD§在线人数§最大人数"
+func parseLegacyResponse(response string) (*LegacyServerStatus, error) {
+	status := &LegacyServerStatus{}
+
+	if strings.HasPrefix(response, "§1\x00") {
+		parts := strings.Split(response, "\x00")
+		if len(parts) < 6 {
+			return nil, parseError("legacy", "server info", ErrMalformed, fmt.Sprintf("字段数 %d", len(parts)))
+		}
+
+		status.ProtocolVersion, _ = strconv.Atoi(parts[1])
+		status.Version = parts[2]
+		status.MOTD = parts[3]
+		status.PlayersOnline, _ = strconv.Atoi(parts[4])
+		status.MaxPlayers, _ = strconv.Atoi(parts[5])
+		return status, nil
+	}
+
+	// MOTD本身可能包含§颜色代码，因此从末尾取人数字段
+	parts := strings.Split(response, "§")
+	if len(parts) < 3 {
+		return nil, parseError("legacy", "server info", ErrMalformed, fmt.Sprintf("字段数 %d", len(parts)))
+	}
+
+	online, err := strconv.Atoi(parts[len(parts)-2])
+	if err != nil {
+		return nil, parseError("legacy", "players online", ErrMalformed, err.Error())
+	}
+	max, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return nil, parseError("legacy", "max players", ErrMalformed, err.Error())
+	}
+
+	status.MOTD = strings.Join(parts[:len(parts)-2], "§")
+	status.PlayersOnline = online
+	status.MaxPlayers = max
+	return status, nil
+}
+
+// createLegacyPingHostPacket 创建1.6版本的 MC|PingHost 请求包
+func createLegacyPingHostPacket(host string, port int) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteByte(legacyPingPacketID)
+	buf.WriteByte(0x01)
+	buf.WriteByte(legacyPluginPacketID)
+	writeLegacyString(&buf, "MC|PingHost")
+
+	// 数据长度: 协议版本(1) + 主机名长度(2) + 主机名 + 端口(4)
+	hostUTF16 := utf16.Encode([]rune(host))
+	binary.Write(&buf, binary.BigEndian, uint16(7+len(hostUTF16)*2))
+	buf.WriteByte(legacyPingHostProtocol)
+	writeLegacyString(&buf, host)
+	binary.Write(&buf, binary.BigEndian, int32(port))
+
+	return buf.Bytes()
+}
+
+// writeLegacyString 写入旧版协议字符串 (short长度 + UTF-16BE)
+func writeLegacyString(buf *bytes.Buffer, s string) {
+	encoded := utf16.Encode([]rune(s))
+	binary.Write(buf, binary.BigEndian, uint16(len(encoded)))
+	for _, c := range encoded {
+		binary.Write(buf, binary.BigEndian, c)
+	}
+}
+
+// decodeUTF16BE 解码UTF-16BE字节序列
+func decodeUTF16BE(data []byte) string {
+	chars := make([]uint16, len(data)/2)
+	for i := range chars {
+		chars[i] = binary.BigEndian.Uint16(data[i*2:])
+	}
+	return string(utf16.Decode(chars))
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// 加入检查的结果状态
+const (
+	JoinStatusJoinable = "joinable"     // 服务器接受登录 (进入加密或登录成功阶段)
+	JoinStatusBlocked  = "join-blocked" // 状态查询正常但登录被拒绝 (白名单、维护模式、后端不可用等)
+)
+
+// DefaultLoginCheckUsername 未指定探测用户名时使用的玩家名
+const DefaultLoginCheckUsername = "etaMonitor"
+
+// loginUsernamePattern 合法的玩家名: 3-16位字母、数字或下划线
+var loginUsernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,16}$`)
+
+// IsValidLoginUsername 判断登录检查使用的玩家名是否合法，空字符串表示使用默认值
+func IsValidLoginUsername(username string) bool {
+	return username == "" || loginUsernamePattern.MatchString(username)
+}
+
+// 登录阶段的数据包ID (服务器 -> 客户端)
+const (
+	loginDisconnectPacketID    = 0x00
+	loginEncryptionPacketID    = 0x01
+	loginSuccessPacketID       = 0x02
+	loginCompressionPacketID   = 0x03
+	loginPluginRequestPacketID = 0x04
+)
+
+// Login Start 包格式随协议版本变化
+const (
+	loginStartSignatureProtocol = 759 // 1.19-1.19.2 携带可选的签名数据
+	loginStartOptionalUUID      = 761 // 1.19.3起 可选携带UUID
+	loginStartRequiresUUID      = 764 // 1.20.2起 必须携带UUID
+)
+
+// loginMaxPacketLength 登录阶段允许的最大数据包长度
+const loginMaxPacketLength = 2 << 20
+
+// LoginCheckResult 加入检查的结果
+type LoginCheckResult struct {
+	Status string        `json:"status"`          // JoinStatusJoinable 或 JoinStatusBlocked
+	Stage  string        `json:"stage,omitempty"` // 服务器接受登录时所处的阶段: encryption, success, plugin
+	Reason ChatComponent `json:"reason"`          // 被拒绝时服务器返回的断开原因
+}
+
+// LoginCheck 以探测用户名进入登录状态，判断服务器是否真正接受玩家加入
+// 收到加密请求 (正版验证服务器) 或登录成功 (离线服务器) 时立即断开，不会完成加入
+// protocol 应为状态查询返回的服务器协议号，否则服务器可能以版本不兼容为由拒绝
+func LoginCheck(ctx context.Context, target ProbeTarget, protocol int, username string) (*LoginCheckResult, error) {
+	if username == "" {
+		username = DefaultLoginCheckUsername
+	}
+	if protocol <= 0 {
+		protocol = target.handshakeProtocol()
+	}
+
+	latency := newLatency()
+	conn, err := dialTimed(ctx, "tcp", target.Host, target.Port, &latency)
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %w", err)
+	}
+	defer conn.Close()
+
+	if err = writeProxyHeader(conn, target.ProxyProtocol); err != nil {
+		return nil, err
+	}
+
+	handshake := createHandshakePacket(target.handshakeHost(), target.Port, protocol, handshakeStateLogin)
+	if _, err = conn.Write(handshake); err != nil {
+		return nil, contextError(ctx, fmt.Errorf("发送握手包失败: %w", err))
+	}
+	if _, err = conn.Write(createLoginStartPacket(username, protocol)); err != nil {
+		return nil, contextError(ctx, fmt.Errorf("发送登录包失败: %w", err))
+	}
+
+	compressed := false
+	for {
+		packetID, data, err := readLoginPacket(conn, compressed)
+		if err != nil {
+			return nil, contextError(ctx, err)
+		}
+
+		switch packetID {
+		case loginDisconnectPacketID:
+			result := &LoginCheckResult{Status: JoinStatusBlocked}
+			reason, err := readStringFromReader(bytes.NewReader(data))
+			if err == nil {
+				json.Unmarshal([]byte(reason), &result.Reason)
+			}
+			return result, nil
+		case loginEncryptionPacketID:
+			return &LoginCheckResult{Status: JoinStatusJoinable, Stage: "encryption"}, nil
+		case loginSuccessPacketID:
+			return &LoginCheckResult{Status: JoinStatusJoinable, Stage: "success"}, nil
+		case loginPluginRequestPacketID:
+			// 代理转发或模组加载器的握手请求，说明服务器已接受登录
+			return &LoginCheckResult{Status: JoinStatusJoinable, Stage: "plugin"}, nil
+		case loginCompressionPacketID:
+			// 之后的数据包均使用压缩格式
+			compressed = true
+		default:
+			return nil, fmt.Errorf("登录阶段收到未知的包ID: %d", packetID)
+		}
+	}
+}
+
+// createLoginStartPacket 按协议版本创建 Login Start 包
+func createLoginStartPacket(username string, protocol int) []byte {
+	var buf bytes.Buffer
+	writeVarInt(&buf, 0x00)
+	writeString(&buf, username)
+
+	uuid := offlinePlayerUUID(username)
+	switch {
+	case protocol >= loginStartRequiresUUID:
+		buf.Write(uuid[:])
+	case protocol >= loginStartOptionalUUID:
+		buf.WriteByte(1)
+		buf.Write(uuid[:])
+	case protocol == loginStartSignatureProtocol:
+		buf.WriteByte(0) // 不携带签名数据
+	case protocol == loginStartSignatureProtocol+1:
+		buf.WriteByte(0) // 不携带签名数据
+		buf.WriteByte(1)
+		buf.Write(uuid[:])
+	}
+
+	return withLengthPrefix(buf.Bytes())
+}
+
+// offlinePlayerUUID 计算离线模式下玩家名对应的UUID (基于名称的v3 UUID)
+func offlinePlayerUUID(username string) [16]byte {
+	uuid := md5.Sum([]byte("OfflinePlayer:" + username))
+	uuid[6] = uuid[6]&0x0F | 0x30
+	uuid[8] = uuid[8]&0x3F | 0x80
+	return uuid
+}
+
+// withLengthPrefix 为数据包添加VarInt长度前缀
+func withLengthPrefix(packet []byte) []byte {
+	var buf bytes.Buffer
+	writeVarInt(&buf, int32(len(packet)))
+	buf.Write(packet)
+	return buf.Bytes()
+}
+
+// readLoginPacket 读取一个登录阶段的数据包，返回包ID和包内容
+// 启用压缩后每个包在长度之后带有解压后长度，0表示该包未压缩
+func readLoginPacket(conn io.Reader, compressed bool) (int32, []byte, error) {
+	packet, err := readPacket(conn, "login", loginMaxPacketLength)
+	if err != nil {
+		return 0, nil, fmt.Errorf("读取数据包失败: %w", err)
+	}
+
+	reader := bytes.NewReader(packet)
+	if compressed {
+		dataLength, err := readVarIntFromReader(reader)
+		if err != nil {
+			return 0, nil, fmt.Errorf("读取解压后长度失败: %w", err)
+		}
+		if dataLength < 0 {
+			return 0, nil, parseError("login", "data length", ErrMalformed, fmt.Sprint(dataLength))
+		}
+		if dataLength > loginMaxPacketLength {
+			return 0, nil, parseError("login", "data length", ErrPacketTooLarge, fmt.Sprint(dataLength))
+		}
+		if dataLength > 0 {
+			zr, err := zlib.NewReader(reader)
+			if err != nil {
+				return 0, nil, fmt.Errorf("解压数据包失败: %w", err)
+			}
+			defer zr.Close()
+			data := make([]byte, dataLength)
+			if _, err := io.ReadFull(zr, data); err != nil {
+				return 0, nil, fmt.Errorf("解压数据包失败: %w", err)
+			}
+			reader = bytes.NewReader(data)
+		}
+	}
+
+	packetID, err := readVarIntFromReader(reader)
+	if err != nil {
+		return 0, nil, fmt.Errorf("读取包ID失败: %w", err)
+	}
+	data, _ := io.ReadAll(reader)
+	return packetID, data, nil
+}
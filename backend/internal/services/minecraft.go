@@ -2,12 +2,15 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -36,11 +39,18 @@ type MinecraftServer struct {
 	ServerType    ServerType  `json:"server_type"`
 	Version       VersionInfo `json:"version"`
 	Players       Players     `json:"players"`
-	Description   Description `json:"description"`
+	Description   ChatComponent `json:"description"`
+	Release       string      `json:"release,omitempty"` // 根据协议号识别的正式版本
 	Favicon       string      `json:"favicon,omitempty"`
 	Ping          int         `json:"ping"`
+	Latency       Latency     `json:"latency"` // 各阶段耗时
 	Online        bool        `json:"online"`
 	RawData       interface{} `json:"raw_data,omitempty"`
+
+	// 模组服务器及聊天安全相关信息 (仅Java版)
+	ModInfo             *ModInfo `json:"mod_info,omitempty"`
+	EnforcesSecureChat  *bool    `json:"enforces_secure_chat,omitempty"`
+	PreventsChatReports *bool    `json:"prevents_chat_reports,omitempty"`
 }
 
 // VersionInfo 版本信息结构体
@@ -62,34 +72,6 @@ type PlayerInfo struct {
 	ID   string `json:"id"`
 }
 
-// Description 服务器描述信息
-type Description struct {
-	Text  string `json:"text,omitempty"`
-	Extra []struct {
-		Text  string `json:"text"`
-		Color string `json:"color,omitempty"`
-	} `json:"extra,omitempty"`
-}
-
-// UnmarshalJSON 自定义JSON解析，处理description字段可能是字符串或对象的情况
-func (d *Description) UnmarshalJSON(data []byte) error {
-	// 尝试解析为字符串
-	var str string
-	if err := json.Unmarshal(data, &str); err == nil {
-		d.Text = str
-		return nil
-	}
-	
-	// 尝试解析为对象
-	type Alias Description
-	aux := &struct {
-		*Alias
-	}{
-		Alias: (*Alias)(d),
-	}
-	return json.Unmarshal(data, &aux)
-}
-
 // JavaServerStatus Java版服务器状态结构
 type JavaServerStatus struct {
 	Version struct {
@@ -104,15 +86,21 @@ type JavaServerStatus struct {
 			ID   string `json:"id"`
 		} `json:"sample,omitempty"`
 	} `json:"players"`
-	Description interface{} `json:"description"`
-	Favicon     string      `json:"favicon,omitempty"`
+	Description         ChatComponent  `json:"description"`
+	Favicon             string         `json:"favicon,omitempty"`
+	ForgeData           *ForgeData     `json:"forgeData,omitempty"`
+	ModInfo             *LegacyModInfo `json:"modinfo,omitempty"`
+	IsModded            *bool          `json:"isModded,omitempty"`
+	EnforcesSecureChat  *bool          `json:"enforcesSecureChat,omitempty"`
+	PreventsChatReports *bool          `json:"preventsChatReports,omitempty"`
 }
 
 // BedrockServerStatus 基岩版服务器状态结构
 type BedrockServerStatus struct {
+	Edition       string `json:"edition"` // "MCPE" 或教育版的 "MCEE"
 	MOTD          string `json:"motd"`
-	GameType      string `json:"game_type"`
-	Map           string `json:"map"`
+	Protocol      int    `json:"protocol"`
+	Map           string `json:"map"` // MOTD第二行，通常为世界名称
 	PlayersOnline int    `json:"players_online"`
 	MaxPlayers    int    `json:"max_players"`
 	ServerID      string `json:"server_id"`
@@ -124,14 +112,14 @@ type BedrockServerStatus struct {
 }
 
 // DetectServerType 检测服务器类型
-func DetectServerType(host string, javaPort, bedrockPort int) ServerType {
-	// 首先尝试Java版
-	if isJavaServer(host, javaPort) {
+func DetectServerType(ctx context.Context, host string, javaPort, bedrockPort int) ServerType {
+	// 首先尝试Java版，包括仅支持旧版ping的服务器
+	if isJavaServer(ctx, host, javaPort) || isLegacyJavaServer(ctx, host, javaPort) {
 		return JavaEdition
 	}
 	
 	// 然后尝试基岩版
-	if isBedrockServer(host, bedrockPort) {
+	if isBedrockServer(ctx, host, bedrockPort) {
 		return BedrockEdition
 	}
 	
@@ -139,21 +127,18 @@ func DetectServerType(host string, javaPort, bedrockPort int) ServerType {
 }
 
 // isJavaServer 检测是否为Java版服务器
-func isJavaServer(host string, port int) bool {
+func isJavaServer(ctx context.Context, host string, port int) bool {
 	// 首先尝试解析SRV记录
-	resolvedHost, resolvedPort, _ := ResolveSRV(host, port)
+	resolvedHost, resolvedPort, _ := ResolveSRV(ctx, host, port)
 	
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", resolvedHost, resolvedPort), 10*time.Second)
+	conn, err := dialContext(ctx, "tcp", fmt.Sprintf("%s:%d", resolvedHost, resolvedPort))
 	if err != nil {
 		return false
 	}
 	defer conn.Close()
 	
-	// 设置读写超时
-	conn.SetDeadline(time.Now().Add(10 * time.Second))
-	
 	// 尝试发送握手包
-	handshake := createHandshakePacket(host, port)
+	handshake := createHandshakePacket(host, port, defaultHandshakeProtocol, handshakeStateStatus)
 	if _, err = conn.Write(handshake); err != nil {
 		return false
 	}
@@ -177,17 +162,18 @@ func isJavaServer(host string, port int) bool {
 	return len(responseStr) > 10 // 最基本的长度检查
 }
 
+// isLegacyJavaServer 检测是否为仅支持旧版ping的Java版服务器
+func isLegacyJavaServer(ctx context.Context, host string, port int) bool {
+	_, err := LegacyServerPing(ctx, ProbeTarget{Host: host, Port: port})
+	return err == nil
+}
+
 // isBedrockServer 检测是否为基岩版服务器
-func isBedrockServer(host string, port int) bool {
+func isBedrockServer(ctx context.Context, host string, port int) bool {
 	// 首先尝试解析SRV记录
-	resolvedHost, resolvedPort, _ := ResolveSRV(host, port)
-	
-	addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", resolvedHost, resolvedPort))
-	if err != nil {
-		return false
-	}
+	resolvedHost, resolvedPort, _ := ResolveSRV(ctx, host, port)
 	
-	conn, err := net.DialUDP("udp", nil, addr)
+	conn, err := dialContext(ctx, "udp", fmt.Sprintf("%s:%d", resolvedHost, resolvedPort))
 	if err != nil {
 		return false
 	}
@@ -202,150 +188,100 @@ func isBedrockServer(host string, port int) bool {
 	
 	// 尝试读取响应
 	buffer := make([]byte, 1024)
-	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
 	_, err = conn.Read(buffer)
 	return err == nil
 }
 
 // JavaServerPing 查询Java版服务器状态
-func JavaServerPing(host string, port int) (*MinecraftServer, error) {
-	// 首先尝试解析SRV记录
-	resolvedHost, resolvedPort, err := ResolveSRV(host, port)
-	if err != nil {
-		// SRV解析失败时记录日志但继续使用原始主机和端口
-		fmt.Printf("SRV解析失败，使用原始地址 %s:%d, 错误: %v\n", host, port, err)
-		resolvedHost, resolvedPort = host, port
+// 服务器不支持现代握手协议 (1.6及更早) 时自动尝试旧版的服务器列表ping
+// 其他错误 (包括1.7+服务器返回无法解析的状态) 直接返回，不再尝试旧版ping
+func JavaServerPing(ctx context.Context, target ProbeTarget) (*MinecraftServer, error) {
+	server, err := javaModernPing(ctx, target)
+	if err == nil {
+		return server, nil
 	}
-	
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", resolvedHost, resolvedPort), 15*time.Second)
+	if ctx.Err() != nil {
+		return nil, contextError(ctx, err)
+	}
+	if !errors.Is(err, errLegacyServer) {
+		return nil, err
+	}
+
+	legacyServer, legacyErr := LegacyServerPing(ctx, target)
+	if legacyErr != nil {
+		return nil, fmt.Errorf("%w; %w", err, legacyErr)
+	}
+	return legacyServer, nil
+}
+
+// errLegacyServer 服务器不支持1.7+的握手协议: 收到握手后直接断开连接，或返回旧版的踢出包
+var errLegacyServer = errors.New("服务器不支持1.7+状态协议")
+
+// isConnectionClosed 判断错误是否为对方关闭或重置了连接
+func isConnectionClosed(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
+
+// javaModernPing 使用1.7+的握手及状态协议查询Java版服务器状态
+func javaModernPing(ctx context.Context, target ProbeTarget) (*MinecraftServer, error) {
+	// 解析SRV记录及主机地址后连接，分阶段记录耗时
+	latency := newLatency()
+	conn, err := dialTimed(ctx, "tcp", target.Host, target.Port, &latency)
 	if err != nil {
-		return nil, fmt.Errorf("连接失败: %v", err)
+		return nil, fmt.Errorf("连接失败: %w", err)
 	}
 	defer conn.Close()
 	
-	startTime := time.Now()
+	if err = writeProxyHeader(conn, target.ProxyProtocol); err != nil {
+		return nil, err
+	}
 	
-	// 设置读写超时
-	conn.SetDeadline(time.Now().Add(15 * time.Second))
+	startTime := time.Now()
 	
-	// 发送握手包 - 使用原始主机名但连接到解析后的地址
-	handshake := createHandshakePacket(host, port)
+	// 发送握手包 - 使用原始主机名(或指定的虚拟主机名)但连接到解析后的地址
+	handshake := createHandshakePacket(target.handshakeHost(), target.Port, target.handshakeProtocol(), handshakeStateStatus)
 	if _, err = conn.Write(handshake); err != nil {
-		return nil, fmt.Errorf("发送握手包失败: %v", err)
+		return nil, fmt.Errorf("发送握手包失败: %w", err)
 	}
 	
 	// 发送状态请求
 	statusRequest := createStatusRequestPacket()
 	if _, err = conn.Write(statusRequest); err != nil {
-		return nil, fmt.Errorf("发送状态请求失败: %v", err)
-	}
-	
-	// 使用更健壮的响应读取方法
-	buffer := make([]byte, 65536) // 增加缓冲区大小
-	totalRead := 0
-	
-	// 设置较长的初始超时
-	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
-	
-	// 先读取包头信息
-	headerBuffer := make([]byte, 16) // 足够读取包长度和包ID
-	headerRead, err := conn.Read(headerBuffer)
-	if err != nil {
-		return nil, fmt.Errorf("读取包头失败: %v", err)
-	}
-	
-	copy(buffer, headerBuffer[:headerRead])
-	totalRead = headerRead
-	
-	// 解析包长度
-	reader := bytes.NewReader(buffer[:totalRead])
-	packetLen, err := readVarIntFromReader(reader)
-	if err != nil {
-		return nil, fmt.Errorf("读取包长度失败: %v", err)
-	}
-	
-	// 计算需要的总字节数 (包长度 + VarInt编码的包长度字节数)
-	packetLenBytes := getVarIntSize(packetLen)
-	totalNeeded := int(packetLen) + packetLenBytes
-	
-	// 如果缓冲区还没有完整包，继续读取
-	for totalRead < totalNeeded && totalRead < len(buffer) {
-		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
-		n, err := conn.Read(buffer[totalRead:])
-		if err != nil {
-			if totalRead >= 10 { // 如果有基本的数据，尝试解析
-				break
-			}
-			return nil, fmt.Errorf("读取响应数据失败: %v", err)
-		}
-		totalRead += n
-		
-		// 避免读取过多数据
-		if totalRead >= totalNeeded {
-			break
+		if isConnectionClosed(err) {
+			return nil, fmt.Errorf("%w: 发送状态请求失败: %w", errLegacyServer, err)
 		}
+		return nil, fmt.Errorf("发送状态请求失败: %w", err)
 	}
 	
-	if totalRead == 0 {
-		return nil, fmt.Errorf("未读取到任何响应数据")
+	// 旧版服务器无法识别握手包，会直接断开连接或返回0xFF踢出包
+	// 踢出包的字符串长度为两个字节，短消息的第二个字节为0；现代协议的VarInt长度不会以 0xFF 0x00 开头
+	header := make([]byte, 2)
+	if _, err = io.ReadFull(conn, header); err != nil {
+		if isConnectionClosed(err) && ctx.Err() == nil {
+			return nil, fmt.Errorf("%w: 读取状态响应失败: %w", errLegacyServer, err)
+		}
+		return nil, contextError(ctx, fmt.Errorf("读取状态响应失败: %w", err))
 	}
-	
-	// 重新解析响应
-	reader = bytes.NewReader(buffer[:totalRead])
-	
-	// 读取包长度 (跳过，我们已经知道了)
-	_, err = readVarIntFromReader(reader)
-	if err != nil {
-		return nil, fmt.Errorf("读取包长度失败: %v", err)
+	if header[0] == legacyKickPacketID && header[1] == 0x00 {
+		return nil, fmt.Errorf("%w: 收到旧版踢出包", errLegacyServer)
 	}
 	
-	// 读取包ID
-	packetID, err := readVarIntFromReader(reader)
+	// 读取状态响应，包长度和JSON长度在分配内存前均会校验
+	packet, err := readPacket(io.MultiReader(bytes.NewReader(header), conn), "java", maxStatusPacketLength)
 	if err != nil {
-		return nil, fmt.Errorf("读取包ID失败: %v", err)
+		return nil, contextError(ctx, fmt.Errorf("读取状态响应失败: %w", err))
 	}
+	latency.Status = millis(time.Since(startTime))
 	
-	if packetID != 0x00 {
-		return nil, fmt.Errorf("无效的包ID: %d", packetID)
-	}
-	
-	// 读取JSON长度
-	jsonLength, err := readVarIntFromReader(reader)
+	javaStatus, err := decodeJavaStatusPacket(packet)
 	if err != nil {
-		return nil, fmt.Errorf("读取JSON长度失败: %v", err)
+		return nil, err
 	}
 	
-	// 计算剩余可读字节数
-	remainingBytes := reader.Len()
-	actualJsonLength := minInt(int(jsonLength), remainingBytes)
-	
-	// 读取实际可用的JSON数据
-	jsonData := make([]byte, actualJsonLength)
-	n, err := reader.Read(jsonData)
-	if err != nil {
-		return nil, fmt.Errorf("读取JSON数据失败: %v", err)
-	}
-	
-	if n < actualJsonLength {
-		jsonData = jsonData[:n] // 截断到实际读取的长度
-	}
-	
-	// 尝试解析JSON，如果数据不完整，尝试修复
-	var javaStatus JavaServerStatus
-	if err = json.Unmarshal(jsonData, &javaStatus); err != nil {
-		// 如果JSON不完整，尝试找到完整的部分
-		jsonStr := string(jsonData)
-		
-		// 尝试找到最后一个完整的JSON结构
-		lastBraceIndex := strings.LastIndex(jsonStr, "}")
-		if lastBraceIndex > 0 {
-			truncatedJson := jsonStr[:lastBraceIndex+1]
-			if err = json.Unmarshal([]byte(truncatedJson), &javaStatus); err != nil {
-				return nil, fmt.Errorf("解析JSON失败: %v (尝试修复后的数据: %s)", err, truncatedJson[:minInt(200, len(truncatedJson))])
-			}
-		} else {
-			return nil, fmt.Errorf("解析JSON失败: %v (原始数据前200字符: %s)", err, jsonStr[:minInt(200, len(jsonStr))])
-		}
+	// 测量ping往返，失败不影响状态结果
+	if rtt, err := javaPingPong(conn); err == nil {
+		latency.Ping = rtt
 	}
 	
 	// 转换为统一格式
@@ -359,9 +295,10 @@ func JavaServerPing(host string, port int) (*MinecraftServer, error) {
 			Online: javaStatus.Players.Online,
 			Max:    javaStatus.Players.Max,
 		},
-		Ping:    int(time.Since(startTime).Milliseconds()),
+		Ping:    latency.RoundTrip(),
+		Latency: latency,
 		Online:  true,
-		RawData: javaStatus,
+		RawData: *javaStatus,
 	}
 	
 	// 转换玩家样本
@@ -372,61 +309,54 @@ func JavaServerPing(host string, port int) (*MinecraftServer, error) {
 		})
 	}
 	
-	// 处理描述字段
-	if desc, ok := javaStatus.Description.(string); ok {
-		server.Description.Text = desc
-	} else if descObj, ok := javaStatus.Description.(map[string]interface{}); ok && descObj != nil {
-		if text, exists := descObj["text"]; exists {
-			server.Description.Text = fmt.Sprintf("%v", text)
-		}
-	}
-	
+	server.Release = ResolveRelease(ReleaseEditionJava, javaStatus.Version.Protocol, javaStatus.Version.Name)
+	server.Description = javaStatus.Description
 	server.Favicon = javaStatus.Favicon
+	server.ModInfo = extractModInfo(javaStatus)
+	server.EnforcesSecureChat = javaStatus.EnforcesSecureChat
+	server.PreventsChatReports = javaStatus.PreventsChatReports
 	
 	return server, nil
 }
 
 // BedrockServerPing 查询基岩版服务器状态
-func BedrockServerPing(host string, port int) (*MinecraftServer, error) {
-	// 首先尝试解析SRV记录
-	resolvedHost, resolvedPort, err := ResolveSRV(host, port)
-	if err != nil {
-		// SRV解析失败时记录日志但继续使用原始主机和端口
-		fmt.Printf("SRV解析失败，使用原始地址 %s:%d, 错误: %v\n", host, port, err)
-		resolvedHost, resolvedPort = host, port
-	}
-	
-	addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", resolvedHost, resolvedPort))
+func BedrockServerPing(ctx context.Context, target ProbeTarget) (*MinecraftServer, error) {
+	latency := newLatency()
+	conn, err := dialTimed(ctx, "udp", target.Host, target.Port, &latency)
 	if err != nil {
-		return nil, fmt.Errorf("解析地址失败: %v", err)
+		return nil, contextError(ctx, fmt.Errorf("连接失败: %w", err))
 	}
+	defer conn.Close()
 	
-	conn, err := net.DialUDP("udp", nil, addr)
-	if err != nil {
-		return nil, fmt.Errorf("连接失败: %v", err)
+	// 发送Unconnected Ping，UDP下PROXY协议头(仅v2)需与数据位于同一个数据报中
+	ping := createUnconnectedPing()
+	if target.ProxyProtocol != ProxyProtocolNone {
+		header, err := proxyHeader(target.ProxyProtocol, conn.LocalAddr(), conn.RemoteAddr())
+		if err != nil {
+			return nil, err
+		}
+		ping = append(header, ping...)
 	}
-	defer conn.Close()
 	
 	startTime := time.Now()
-	
-	// 发送Unconnected Ping
-	ping := createUnconnectedPing()
 	if _, err = conn.Write(ping); err != nil {
-		return nil, fmt.Errorf("发送ping失败: %v", err)
+		return nil, fmt.Errorf("发送ping失败: %w", err)
 	}
 	
 	// 读取响应
-	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
-	buffer := make([]byte, 2048)
+	buffer := make([]byte, maxBedrockPongLength)
 	n, err := conn.Read(buffer)
 	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %v", err)
+		return nil, contextError(ctx, fmt.Errorf("读取响应失败: %w", err))
 	}
+	// Unconnected Pong同时携带状态信息，往返时间即为状态响应耗时
+	latency.Status = millis(time.Since(startTime))
+	latency.Ping = latency.Status
 	
 	// 解析响应
 	bedrockStatus, err := parseBedrockResponse(buffer[:n])
 	if err != nil {
-		return nil, fmt.Errorf("解析响应失败: %v", err)
+		return nil, err
 	}
 	
 	// 转换为统一格式
@@ -434,56 +364,65 @@ func BedrockServerPing(host string, port int) (*MinecraftServer, error) {
 		ServerType: BedrockEdition,
 		Version: VersionInfo{
 			Name:     bedrockStatus.Version,
-			Protocol: 0, // 基岩版协议版本号不固定
+			Protocol: bedrockStatus.Protocol,
 		},
+		Release: ResolveRelease(ReleaseEditionBedrock, bedrockStatus.Protocol, bedrockStatus.Version),
 		Players: Players{
 			Online: bedrockStatus.PlayersOnline,
 			Max:    bedrockStatus.MaxPlayers,
 		},
-		Description: Description{
-			Text: bedrockStatus.MOTD,
-		},
-		Ping:    int(time.Since(startTime).Milliseconds()),
-		Online:  true,
-		RawData: bedrockStatus,
+		Description: ParseLegacyText(bedrockStatus.MOTD),
+		Ping:        latency.RoundTrip(),
+		Latency:     latency,
+		Online:      true,
+		RawData:     bedrockStatus,
 	}
 	
 	return server, nil
 }
 
 // AutoDetectServer 自动检测服务器类型并ping
-func AutoDetectServer(host string, javaPort, bedrockPort int) (*MinecraftServer, string, error) {
-	serverType := DetectServerType(host, javaPort, bedrockPort)
+// 检测时使用默认握手参数，确定类型后按target中的选项ping
+func AutoDetectServer(ctx context.Context, target ProbeTarget, bedrockPort int) (*MinecraftServer, string, error) {
+	serverType := DetectServerType(ctx, target.Host, target.Port, bedrockPort)
 	
 	switch serverType {
 	case JavaEdition:
-		server, err := JavaServerPing(host, javaPort)
+		server, err := JavaServerPing(ctx, target)
 		if err != nil {
-			return nil, "", fmt.Errorf("Java版ping失败: %v", err)
+			return nil, "", fmt.Errorf("Java版ping失败: %w", err)
 		}
 		return server, "java", nil
 		
 	case BedrockEdition:
-		server, err := BedrockServerPing(host, bedrockPort)
+		bedrockTarget := target
+		bedrockTarget.Port = bedrockPort
+		server, err := BedrockServerPing(ctx, bedrockTarget)
 		if err != nil {
-			return nil, "", fmt.Errorf("基岩版ping失败: %v", err)
+			return nil, "", fmt.Errorf("基岩版ping失败: %w", err)
 		}
 		return server, "bedrock", nil
 		
 	default:
-		return nil, "", fmt.Errorf("无法检测服务器类型")
+		return nil, "", contextError(ctx, fmt.Errorf("无法检测服务器类型"))
 	}
 }
 
+// 握手包中的下一个状态
+const (
+	handshakeStateStatus = 1
+	handshakeStateLogin  = 2
+)
+
 // createHandshakePacket 创建握手包
-func createHandshakePacket(host string, port int) []byte {
+func createHandshakePacket(host string, port int, protocol int, nextState int32) []byte {
 	var buf bytes.Buffer
 	
 	// 包ID (0x00) - VarInt
 	writeVarInt(&buf, 0x00)
 	
-	// 协议版本 (VarInt) - 使用更兼容的版本
-	writeVarInt(&buf, 47) // 1.8 协议版本，兼容性更好
+	// 协议版本 (VarInt) - 默认使用兼容性更好的1.8协议版本
+	writeVarInt(&buf, int32(protocol))
 	
 	// 服务器地址
 	writeString(&buf, host)
@@ -491,8 +430,8 @@ func createHandshakePacket(host string, port int) []byte {
 	// 服务器端口
 	binary.Write(&buf, binary.BigEndian, uint16(port))
 	
-	// 下一个状态 (1 = status)
-	writeVarInt(&buf, 1)
+	// 下一个状态 (1 = status, 2 = login)
+	writeVarInt(&buf, nextState)
 	
 	// 添加包长度前缀
 	var final bytes.Buffer
@@ -502,6 +441,42 @@ func createHandshakePacket(host string, port int) []byte {
 	return final.Bytes()
 }
 
+// javaPingPong 在状态连接上发送0x01 ping包并等待服务器原样返回的pong，返回往返耗时(毫秒)
+func javaPingPong(conn net.Conn) (int, error) {
+	payload := time.Now().UnixNano()
+
+	var packet bytes.Buffer
+	writeVarInt(&packet, 0x01)
+	binary.Write(&packet, binary.BigEndian, payload)
+
+	var buf bytes.Buffer
+	writeVarInt(&buf, int32(packet.Len()))
+	buf.Write(packet.Bytes())
+
+	start := time.Now()
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		return -1, fmt.Errorf("发送ping包失败: %w", err)
+	}
+
+	length, err := readVarInt(conn)
+	if err != nil {
+		return -1, fmt.Errorf("读取pong包长度失败: %w", err)
+	}
+	rtt := millis(time.Since(start))
+	if length != 9 {
+		return -1, fmt.Errorf("无效的pong包长度: %d", length)
+	}
+
+	response := make([]byte, length)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return -1, fmt.Errorf("读取pong包失败: %w", err)
+	}
+	if response[0] != 0x01 || int64(binary.BigEndian.Uint64(response[1:])) != payload {
+		return -1, fmt.Errorf("pong包内容不匹配")
+	}
+	return rtt, nil
+}
+
 // createStatusRequestPacket 创建状态请求包
 func createStatusRequestPacket() []byte {
 	var buf bytes.Buffer
@@ -517,6 +492,9 @@ func createStatusRequestPacket() []byte {
 	return final.Bytes()
 }
 
+// raknetMagic RakNet离线消息中的固定magic
+var raknetMagic = []byte{0x00, 0xFF, 0xFF, 0x00, 0xFE, 0xFE, 0xFE, 0xFE, 0xFD, 0xFD, 0xFD, 0xFD, 0x12, 0x34, 0x56, 0x78}
+
 // createUnconnectedPing 创建Unconnected Ping包
 func createUnconnectedPing() []byte {
 	var buf bytes.Buffer
@@ -528,8 +506,7 @@ func createUnconnectedPing() []byte {
 	binary.Write(&buf, binary.BigEndian, time.Now().Unix())
 	
 	// RakNet Magic
-	magic := []byte{0x00, 0xFF, 0xFF, 0x00, 0xFE, 0xFE, 0xFE, 0xFE, 0xFD, 0xFD, 0xFD, 0xFD, 0x12, 0x34, 0x56, 0x78}
-	buf.Write(magic)
+	buf.Write(raknetMagic)
 	
 	// Client GUID
 	binary.Write(&buf, binary.BigEndian, int64(12345))
@@ -538,97 +515,86 @@ func createUnconnectedPing() []byte {
 }
 
 // parseBedrockResponse 解析基岩版响应
+// 纯函数，不进行任何网络操作，任意输入均不会panic
 func parseBedrockResponse(data []byte) (*BedrockServerStatus, error) {
-	if len(data) < 35 {
-		return nil, fmt.Errorf("响应数据太短")
+	if len(data) > maxBedrockPongLength {
+		return nil, parseError("bedrock", "packet", ErrPacketTooLarge, fmt.Sprint(len(data)))
+	}
+	
+	// PacketID + Timestamp + ServerGUID + Magic + 字符串长度
+	const headerLength = 1 + 8 + 8 + 16 + 2
+	if len(data) < headerLength {
+		return nil, parseError("bedrock", "header", ErrTruncated, fmt.Sprintf("%d 字节", len(data)))
 	}
 	
 	// 检查包ID
 	if data[0] != 0x1C {
-		return nil, fmt.Errorf("无效的包ID: %d", data[0])
+		return nil, parseError("bedrock", "packet id", ErrMalformed, fmt.Sprint(data[0]))
 	}
 	
-	// 跳过时间戳和magic
-	offset := 1 + 8 + 16 + 8 // PacketID + Timestamp + Magic + ServerGUID
-	
-	// 读取字符串长度
-	if offset+2 > len(data) {
-		return nil, fmt.Errorf("数据长度不足")
+	// 跳过时间戳和服务器GUID，校验magic
+	offset := 1 + 8 + 8
+	if !bytes.Equal(data[offset:offset+16], raknetMagic) {
+		return nil, parseError("bedrock", "magic", ErrMalformed, "")
 	}
+	offset += 16
 	
-	strLen := binary.BigEndian.Uint16(data[offset:])
+	strLen := int(binary.BigEndian.Uint16(data[offset:]))
 	offset += 2
 	
 	// 读取服务器信息字符串
-	if offset+int(strLen) > len(data) {
-		return nil, fmt.Errorf("字符串长度超出数据范围")
+	if strLen > len(data)-offset {
+		return nil, parseError("bedrock", "server info", ErrTruncated, fmt.Sprintf("需要 %d 字节，实际 %d 字节", strLen, len(data)-offset))
 	}
 	
-	serverInfo := string(data[offset : offset+int(strLen)])
+	serverInfo := string(data[offset : offset+strLen])
 	
 	// 解析服务器信息
 	parts := strings.Split(serverInfo, ";")
 	if len(parts) < 6 {
-		return nil, fmt.Errorf("服务器信息格式无效")
+		return nil, parseError("bedrock", "server info", ErrMalformed, fmt.Sprintf("字段数 %d", len(parts)))
 	}
 	
-	status := &BedrockServerStatus{}
-	
-	// 解析各个字段
-	if len(parts) > 0 {
-		status.MOTD = parts[0]
-	}
-	if len(parts) > 1 {
-		status.GameType = parts[1]
-	}
-	if len(parts) > 2 {
-		status.Map = parts[2]
-	}
-	if len(parts) > 3 {
-		if online, err := strconv.Atoi(parts[3]); err == nil {
-			status.PlayersOnline = online
-		}
-	}
-	if len(parts) > 4 {
-		if max, err := strconv.Atoi(parts[4]); err == nil {
-			status.MaxPlayers = max
-		}
-	}
-	if len(parts) > 5 {
-		status.ServerID = parts[5]
+	// 格式: 版本类型;MOTD;协议号;版本号;在线人数;最大人数;服务器ID;MOTD第二行;游戏模式;游戏模式编号;IPv4端口;IPv6端口;
+	status := &BedrockServerStatus{
+		Edition: parts[0],
+		MOTD:    parts[1],
+		Version: parts[3],
 	}
+	status.Protocol, _ = strconv.Atoi(parts[2])
+	status.PlayersOnline, _ = strconv.Atoi(parts[4])
+	status.MaxPlayers, _ = strconv.Atoi(parts[5])
 	if len(parts) > 6 {
-		status.GameMode = parts[6]
+		status.ServerID = parts[6]
 	}
 	if len(parts) > 7 {
-		if mode, err := strconv.Atoi(parts[7]); err == nil {
-			status.GameModeNum = mode
-		}
+		status.Map = parts[7]
 	}
 	if len(parts) > 8 {
-		if port, err := strconv.Atoi(parts[8]); err == nil {
-			status.PortIPv4 = port
-		}
+		status.GameMode = parts[8]
 	}
 	if len(parts) > 9 {
-		if port, err := strconv.Atoi(parts[9]); err == nil {
-			status.PortIPv6 = port
-		}
+		status.GameModeNum, _ = strconv.Atoi(parts[9])
 	}
 	if len(parts) > 10 {
-		status.Version = parts[10]
+		status.PortIPv4, _ = strconv.Atoi(parts[10])
+	}
+	if len(parts) > 11 {
+		status.PortIPv6, _ = strconv.Atoi(parts[11])
 	}
 	
 	return status, nil
 }
 
 // writeVarInt 写入VarInt
+// 负数 (如协议号-1) 按无符号数编码为5个字节
 func writeVarInt(buf *bytes.Buffer, value int32) {
-	for value >= 0x80 {
-		buf.WriteByte(byte(value) | 0x80)
-		value >>= 7
+	v := uint32(value)
+	for v >= 0x80 {
+		buf.WriteByte(byte(v) | 0x80)
+		v >>= 7
 	}
-	buf.WriteByte(byte(value))
+	buf.WriteByte(byte(v))
 }
 
 // writeString 写入字符串
@@ -638,13 +604,13 @@ func writeString(buf *bytes.Buffer, s string) {
 }
 
 // readVarInt 读取VarInt
-func readVarInt(conn net.Conn) (int32, error) {
+func readVarInt(r io.Reader) (int32, error) {
 	var result int32
 	var shift uint
+	b := make([]byte, 1)
 	
 	for {
-		b := make([]byte, 1)
-		if _, err := conn.Read(b); err != nil {
+		if _, err := io.ReadFull(r, b); err != nil {
 			return 0, err
 		}
 		
@@ -656,7 +622,7 @@ func readVarInt(conn net.Conn) (int32, error) {
 		
 		shift += 7
 		if shift >= 32 {
-			return 0, fmt.Errorf("VarInt太长")
+			return 0, fmt.Errorf("VarInt太长: %w", ErrMalformed)
 		}
 	}
 	
@@ -682,29 +648,31 @@ func readVarIntFromReader(reader *bytes.Reader) (int32, error) {
 		
 		shift += 7
 		if shift >= 32 {
-			return 0, fmt.Errorf("VarInt太长")
+			return 0, fmt.Errorf("VarInt太长: %w", ErrMalformed)
 		}
 	}
 	
 	return result, nil
 }
 
-// getVarIntSize 计算VarInt编码需要的字节数
-func getVarIntSize(value int32) int {
-	if value == 0 {
-		return 1
+// readStringFromReader 从Reader读取VarInt长度前缀的UTF-8字符串
+func readStringFromReader(reader *bytes.Reader) (string, error) {
+	length, err := readVarIntFromReader(reader)
+	if err != nil {
+		return "", err
 	}
-	
-	size := 0
-	for value > 0 {
-		size++
-		value >>= 7
+	if length < 0 || int(length) > reader.Len() {
+		return "", fmt.Errorf("字符串长度超出数据范围: %d", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return "", err
 	}
-	return size
+	return string(data), nil
 }
 
 // ResolveSRV 解析SRV记录获取实际的主机和端口
-func ResolveSRV(host string, port int) (string, int, error) {
+func ResolveSRV(ctx context.Context, host string, port int) (string, int, error) {
 	// 如果端口不是默认端口，说明用户明确指定了端口，跳过SRV查询
 	if port != 25565 && port != 19132 {
 		return host, port, nil
@@ -718,8 +686,16 @@ func ResolveSRV(host string, port int) (string, int, error) {
 	}
 	
 	// 尝试查询SRV记录
-	_, addrs, err := net.LookupSRV(service, "tcp", host)
-	if err != nil || len(addrs) == 0 {
+	_, addrs, err := net.DefaultResolver.LookupSRV(ctx, service, "tcp", host)
+	if err != nil {
+		// 没有SRV记录属于正常情况，其他查询错误返回给调用方，同时仍返回原始主机和端口
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return host, port, nil
+		}
+		return host, port, &SRVError{Err: err}
+	}
+	if len(addrs) == 0 {
 		// 没有SRV记录，使用原始主机和端口
 		return host, port, nil
 	}
@@ -738,4 +714,21 @@ func minInt(a, b int) int {
 		return a
 	}
 	return b
-}
\ No newline at end of file
+}
+// StripFormattingCodes 去除文本中的§格式代码
+func StripFormattingCodes(s string) string {
+	if !strings.ContainsRune(s, '§') {
+		return s
+	}
+
+	var result strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '§' {
+			i++ // 跳过格式代码字符
+			continue
+		}
+		result.WriteRune(runes[i])
+	}
+	return result.String()
+}
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"etamonitor/internal/events"
 	"etamonitor/internal/models"
 	"etamonitor/internal/websocket"
 	"gorm.io/gorm"
@@ -240,7 +241,7 @@ func (p *PlayerSessionService) handlePlayerLeave(server *models.Server, playerNa
 	p.db.Save(&player)
 	
 	// 更新玩家等级
-	p.updatePlayerRank(&player)
+	p.updatePlayerRank(&player, server)
 	
 	// 保存玩家活动记录
 	p.savePlayerActivity(player.ID, server.ID, "leave", duration)
@@ -296,8 +297,8 @@ func (p *PlayerSessionService) findOrCreatePlayer(username, uuid string) *models
 	return &player
 }
 
-// updatePlayerRank 更新玩家等级
-func (p *PlayerSessionService) updatePlayerRank(player *models.Player) {
+// updatePlayerRank 更新玩家等级，server 为玩家刚离开的服务器
+func (p *PlayerSessionService) updatePlayerRank(player *models.Player, server *models.Server) {
 	playtimeHours := float64(player.TotalPlaytime) / 3600.0
 	
 	var newRank string
@@ -327,12 +328,12 @@ func (p *PlayerSessionService) updatePlayerRank(player *models.Player) {
 			player.Username, oldRank, newRank, playtimeHours)
 		
 		// TODO: 可以在这里添加称号系统的检查
-		p.checkAndAwardTitles(player)
+		p.checkAndAwardTitles(player, server)
 	}
 }
 
 // checkAndAwardTitles 检查并授予称号
-func (p *PlayerSessionService) checkAndAwardTitles(player *models.Player) {
+func (p *PlayerSessionService) checkAndAwardTitles(player *models.Player, server *models.Server) {
 	// 获取玩家的在线时间统计
 	var sessions []models.PlayerSession
 	p.db.Where("player_id = ? AND leave_time IS NOT NULL", player.ID).Find(&sessions)
@@ -366,42 +367,42 @@ func (p *PlayerSessionService) checkAndAwardTitles(player *models.Player) {
 	if totalSessions > 0 {
 		// 夜猫子称号 (30%以上的会话在夜晚)
 		if float64(nightOwlSessions)/float64(totalSessions) >= 0.3 {
-			p.awardTitle(player.ID, "夜猫子")
+			p.awardTitle(player, server, "夜猫子")
 		}
 		
 		// 早鸟称号 (20%以上的会话在早晨)
 		if float64(earlyBirdSessions)/float64(totalSessions) >= 0.2 {
-			p.awardTitle(player.ID, "早鸟")
+			p.awardTitle(player, server, "早鸟")
 		}
 		
 		// 周末战士称号 (40%以上的会话在周末)
 		if float64(weekendSessions)/float64(totalSessions) >= 0.4 {
-			p.awardTitle(player.ID, "周末战士")
+			p.awardTitle(player, server, "周末战士")
 		}
 	}
 	
 	// 基于总在线时间的称号
 	playtimeHours := float64(player.TotalPlaytime) / 3600.0
 	if playtimeHours >= 100 {
-		p.awardTitle(player.ID, "时间管理大师")
+		p.awardTitle(player, server, "时间管理大师")
 	}
 	if playtimeHours >= 1000 {
-		p.awardTitle(player.ID, "传奇玩家")
+		p.awardTitle(player, server, "传奇玩家")
 	}
 }
 
 // awardTitle 授予称号
-func (p *PlayerSessionService) awardTitle(playerID uint, title string) {
+func (p *PlayerSessionService) awardTitle(player *models.Player, server *models.Server, title string) {
 	// 检查是否已经有这个称号
 	var existingTitle models.PlayerTitle
-	err := p.db.Where("player_id = ? AND title = ?", playerID, title).First(&existingTitle).Error
+	err := p.db.Where("player_id = ? AND title = ?", player.ID, title).First(&existingTitle).Error
 	if err == nil {
 		return // 已经有这个称号
 	}
 	
 	// 创建新称号
 	newTitle := models.PlayerTitle{
-		PlayerID: playerID,
+		PlayerID: player.ID,
 		Title:    title,
 		EarnedAt: time.Now(),
 	}
@@ -411,7 +412,17 @@ func (p *PlayerSessionService) awardTitle(playerID uint, title string) {
 		return
 	}
 	
-	log.Printf("玩家获得新称号: 玩家ID=%d, 称号=%s", playerID, title)
+	log.Printf("玩家获得新称号: 玩家ID=%d, 称号=%s", player.ID, title)
+
+	events.Publish(events.PlayerTitleAwarded, server.ID, map[string]interface{}{
+		"username":    player.Username,
+		"uuid":        player.UUID,
+		"server_name": server.Name,
+		"rank":        player.Rank,
+		"title":       title,
+		"earned_at":   newTitle.EarnedAt,
+		"avatar":      p.getPlayerAvatar(player.Username),
+	})
 }
 
 // savePlayerActivity 保存玩家活动记录
@@ -441,6 +452,7 @@ func (p *PlayerSessionService) broadcastPlayerJoin(serverID uint, player *models
 	}
 	
 	websocket.BroadcastPlayerJoin(serverID, data)
+	events.Publish(events.PlayerJoin, serverID, data)
 }
 
 // broadcastPlayerLeave 广播玩家离开消息
@@ -456,6 +468,7 @@ func (p *PlayerSessionService) broadcastPlayerLeave(serverID uint, player *model
 	}
 	
 	websocket.BroadcastPlayerLeave(serverID, data)
+	events.Publish(events.PlayerLeave, serverID, data)
 }
 
 // getPlayerAvatar 获取玩家头像URL
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"etamonitor/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestPlayerSessionService(t *testing.T) (*PlayerSessionService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.Server{}, &models.Player{}, &models.PlayerSession{},
+		&models.PlayerActivity{}, &models.PlayerTitle{}); err != nil {
+		t.Fatal(err)
+	}
+	return NewPlayerSessionService(db), db
+}
+
+// Query和RCON来源只有玩家名，多个UUID未知的玩家不能因唯一索引冲突而丢失
+func TestUpdatePlayerSessionsWithoutUUIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		players []PlayerInfo
+	}{
+		{
+			name:    "query",
+			source:  "query",
+			players: (&QueryResult{Players: []string{"Steve", "Alex", "Notch"}}).PlayerInfos(),
+		},
+		{
+			name:    "rcon list",
+			source:  "rcon",
+			players: ParseListResponse("There are 3 of a max of 20 players online: Steve, Alex, Notch"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, db := newTestPlayerSessionService(t)
+			server := models.Server{Name: "test", Address: "127.0.0.1", Type: "java", PlayerSource: tt.source}
+			if err := db.Create(&server).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			service.UpdatePlayerSessions(&server, tt.players)
+
+			var players int64
+			db.Model(&models.Player{}).Where("uuid = ?", "").Count(&players)
+			if players != int64(len(tt.players)) {
+				t.Fatalf("players without uuid = %d, want %d", players, len(tt.players))
+			}
+			var sessions int64
+			db.Model(&models.PlayerSession{}).Where("server_id = ? AND leave_time IS NULL", server.ID).Count(&sessions)
+			if sessions != int64(len(tt.players)) {
+				t.Fatalf("open sessions = %d, want %d", sessions, len(tt.players))
+			}
+		})
+	}
+}
+
+// 已知UUID仍然唯一
+func TestFindOrCreatePlayerKnownUUID(t *testing.T) {
+	service, db := newTestPlayerSessionService(t)
+
+	const uuid = "069a79f4-44e9-4726-a5be-fca90e38aaf5"
+	first := service.findOrCreatePlayer("Notch", uuid)
+	if first == nil {
+		t.Fatal("player not created")
+	}
+	// 改名后仍按UUID找到同一玩家
+	renamed := service.findOrCreatePlayer("Notch2", uuid)
+	if renamed == nil || renamed.ID != first.ID {
+		t.Fatalf("renamed player = %+v, want id %d", renamed, first.ID)
+	}
+
+	duplicate := models.Player{Username: "Other", UUID: uuid}
+	if err := db.Create(&duplicate).Error; err == nil {
+		t.Fatal("duplicate uuid accepted")
+	}
+}
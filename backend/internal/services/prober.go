@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"etamonitor/internal/models"
+)
+
+// 基岩版默认端口，自动检测时使用
+const defaultBedrockPort = 19132
+
+// defaultHandshakeProtocol 未指定时握手包中声明的协议版本 (1.8)，兼容性最好
+const defaultHandshakeProtocol = 47
+
+// ProbeTarget 探测目标
+type ProbeTarget struct {
+	Host string
+	Port int
+
+	// 以下选项用于代理后的服务器 (如Velocity forced-hosts、TCPShield、HAProxy)
+	HandshakeHost     string // 握手包中发送的主机名，为空时使用Host
+	HandshakeProtocol int    // 握手包中声明的协议版本，0表示使用默认值
+	ProxyProtocol     string // 连接建立后发送的PROXY协议头: "", "v1", "v2"
+}
+
+// ServerProbeTarget 根据服务器配置创建探测目标
+func ServerProbeTarget(server *models.Server) ProbeTarget {
+	return ProbeTarget{
+		Host:              server.Address,
+		Port:              server.Port,
+		HandshakeHost:     server.HandshakeHost,
+		HandshakeProtocol: server.HandshakeProtocol,
+		ProxyProtocol:     server.ProxyProtocol,
+	}
+}
+
+// handshakeHost 返回握手包中使用的主机名
+func (t ProbeTarget) handshakeHost() string {
+	if t.HandshakeHost != "" {
+		return t.HandshakeHost
+	}
+	return t.Host
+}
+
+// handshakeProtocol 返回握手包中声明的协议版本
+func (t ProbeTarget) handshakeProtocol() int {
+	if t.HandshakeProtocol != 0 {
+		return t.HandshakeProtocol
+	}
+	return defaultHandshakeProtocol
+}
+
+// Prober 服务器探测器，每种服务器类型对应一个实现
+type Prober interface {
+	// Name 探测器名称，与服务器的 type 字段对应
+	Name() string
+	// Description 探测器的简要说明
+	Description() string
+	// DefaultPort 该类型服务器的默认端口
+	DefaultPort() int
+	// Probe 探测服务器状态，ctx取消时应立即返回
+	Probe(ctx context.Context, target ProbeTarget) (*MinecraftServer, error)
+}
+
+// ProberInfo 探测器的描述信息，用于API展示
+type ProberInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	DefaultPort int    `json:"default_port"`
+}
+
+var (
+	probersMu sync.RWMutex
+	probers   = make(map[string]Prober)
+)
+
+// RegisterProber 注册探测器，名称重复时panic
+func RegisterProber(p Prober) {
+	probersMu.Lock()
+	defer probersMu.Unlock()
+
+	if _, exists := probers[p.Name()]; exists {
+		panic(fmt.Sprintf("探测器 %s 重复注册", p.Name()))
+	}
+	probers[p.Name()] = p
+}
+
+// GetProber 根据名称获取探测器
+func GetProber(name string) (Prober, bool) {
+	probersMu.RLock()
+	defer probersMu.RUnlock()
+
+	p, ok := probers[name]
+	return p, ok
+}
+
+// ProberFor 返回服务器类型对应的探测器，未知类型按Java版处理
+func ProberFor(serverType string) Prober {
+	if p, ok := GetProber(serverType); ok {
+		return p
+	}
+	p, _ := GetProber("java")
+	return p
+}
+
+// ProberNames 返回所有已注册的探测器名称
+func ProberNames() []string {
+	probersMu.RLock()
+	defer probersMu.RUnlock()
+
+	names := make([]string, 0, len(probers))
+	for name := range probers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProberInfos 返回所有已注册探测器的描述信息
+func ProberInfos() []ProberInfo {
+	names := ProberNames()
+	infos := make([]ProberInfo, 0, len(names))
+	for _, name := range names {
+		p, _ := GetProber(name)
+		infos = append(infos, ProberInfo{
+			Name:        p.Name(),
+			Description: p.Description(),
+			DefaultPort: p.DefaultPort(),
+		})
+	}
+	return infos
+}
+
+func init() {
+	RegisterProber(javaProber{})
+	RegisterProber(bedrockProber{})
+	RegisterProber(autoProber{})
+}
+
+// javaProber Java版服务器列表ping (含旧版ping回退)
+type javaProber struct{}
+
+func (javaProber) Name() string        { return "java" }
+func (javaProber) Description() string { return "Java版服务器列表Ping" }
+func (javaProber) DefaultPort() int    { return 25565 }
+
+func (javaProber) Probe(ctx context.Context, target ProbeTarget) (*MinecraftServer, error) {
+	return JavaServerPing(ctx, target)
+}
+
+// bedrockProber 基岩版RakNet Unconnected Ping
+type bedrockProber struct{}
+
+func (bedrockProber) Name() string        { return "bedrock" }
+func (bedrockProber) Description() string { return "基岩版RakNet Ping" }
+func (bedrockProber) DefaultPort() int    { return defaultBedrockPort }
+
+func (bedrockProber) Probe(ctx context.Context, target ProbeTarget) (*MinecraftServer, error) {
+	return BedrockServerPing(ctx, target)
+}
+
+// autoProber 依次尝试Java版与基岩版，结果中的 ServerType 为检测到的类型
+type autoProber struct{}
+
+func (autoProber) Name() string        { return "auto" }
+func (autoProber) Description() string { return "自动检测Java版或基岩版" }
+func (autoProber) DefaultPort() int    { return 25565 }
+
+func (autoProber) Probe(ctx context.Context, target ProbeTarget) (*MinecraftServer, error) {
+	server, _, err := AutoDetectServer(ctx, target, defaultBedrockPort)
+	return server, err
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// PROXY协议版本，用于探测只接受来自代理 (如HAProxy、TCPShield) 连接的服务器
+const (
+	ProxyProtocolNone = ""
+	ProxyProtocolV1   = "v1"
+	ProxyProtocolV2   = "v2"
+)
+
+// proxyV2Signature PROXY协议v2的固定签名
+var proxyV2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+// IsValidProxyProtocol 判断PROXY协议版本是否受支持，空字符串表示不发送
+func IsValidProxyProtocol(version string) bool {
+	switch version {
+	case ProxyProtocolNone, ProxyProtocolV1, ProxyProtocolV2:
+		return true
+	default:
+		return false
+	}
+}
+
+// proxyHeader 根据连接的本地与远端地址生成PROXY协议头
+// 源地址为本机地址，目标地址为服务器地址；v1只支持TCP
+func proxyHeader(version string, local, remote net.Addr) ([]byte, error) {
+	srcIP, srcPort, stream := splitAddr(local)
+	dstIP, dstPort, _ := splitAddr(remote)
+	if srcIP == nil || dstIP == nil {
+		return nil, fmt.Errorf("无法识别的连接地址: %v -> %v", local, remote)
+	}
+
+	// 源与目标地址族不同时统一使用IPv6格式
+	ipv4 := srcIP.To4() != nil && dstIP.To4() != nil
+
+	switch version {
+	case ProxyProtocolV1:
+		if !stream {
+			return nil, fmt.Errorf("PROXY协议v1不支持UDP")
+		}
+		family := "TCP6"
+		if ipv4 {
+			family = "TCP4"
+			srcIP, dstIP = srcIP.To4(), dstIP.To4()
+		}
+		return []byte(fmt.Sprintf("PROXY %s %s %s %d %d\r\n", family, srcIP, dstIP, srcPort, dstPort)), nil
+
+	case ProxyProtocolV2:
+		var buf bytes.Buffer
+		buf.Write(proxyV2Signature)
+		buf.WriteByte(0x21) // 版本2，PROXY命令
+
+		// 高4位为地址族 (1=IPv4, 2=IPv6)，低4位为传输协议 (1=STREAM, 2=DGRAM)
+		transport := byte(0x01)
+		if !stream {
+			transport = 0x02
+		}
+		var src, dst []byte
+		if ipv4 {
+			buf.WriteByte(0x10 | transport)
+			src, dst = srcIP.To4(), dstIP.To4()
+		} else {
+			buf.WriteByte(0x20 | transport)
+			src, dst = srcIP.To16(), dstIP.To16()
+		}
+
+		binary.Write(&buf, binary.BigEndian, uint16(len(src)+len(dst)+4))
+		buf.Write(src)
+		buf.Write(dst)
+		binary.Write(&buf, binary.BigEndian, uint16(srcPort))
+		binary.Write(&buf, binary.BigEndian, uint16(dstPort))
+		return buf.Bytes(), nil
+
+	default:
+		return nil, fmt.Errorf("不支持的PROXY协议版本: %s", version)
+	}
+}
+
+// splitAddr 提取地址中的IP和端口，stream 表示是否为TCP连接
+func splitAddr(addr net.Addr) (net.IP, int, bool) {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP, a.Port, true
+	case *net.UDPAddr:
+		return a.IP, a.Port, false
+	default:
+		return nil, 0, false
+	}
+}
+
+// writeProxyHeader 在连接建立后首先发送PROXY协议头，version为空时不发送
+func writeProxyHeader(conn net.Conn, version string) error {
+	if version == ProxyProtocolNone {
+		return nil
+	}
+	header, err := proxyHeader(version, conn.LocalAddr(), conn.RemoteAddr())
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(header); err != nil {
+		return fmt.Errorf("发送PROXY协议头失败: %w", err)
+	}
+	return nil
+}
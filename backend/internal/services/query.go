@@ -0,0 +1,279 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GameSpy4 Query 协议常量
+const (
+	queryMagicHigh     = 0xFE
+	queryMagicLow      = 0xFD
+	queryTypeHandshake = 0x09
+	queryTypeStat      = 0x00
+)
+
+// QueryResult Query协议返回的完整服务器信息
+type QueryResult struct {
+	MOTD       string            `json:"motd"`
+	GameType   string            `json:"game_type"`
+	GameID     string            `json:"game_id"`
+	Version    string            `json:"version"`
+	Software   string            `json:"software"`
+	Plugins    []string          `json:"plugins"`
+	Map        string            `json:"map"`
+	NumPlayers int               `json:"num_players"`
+	MaxPlayers int               `json:"max_players"`
+	HostPort   int               `json:"host_port"`
+	HostIP     string            `json:"host_ip"`
+	Players    []string          `json:"players"`
+	Raw        map[string]string `json:"raw,omitempty"`
+	Latency    Latency           `json:"latency"`
+}
+
+// PlayerInfos 将Query返回的玩家名转换为统一的玩家信息列表
+// Query协议不提供UUID，因此ID字段为空
+func (q *QueryResult) PlayerInfos() []PlayerInfo {
+	players := make([]PlayerInfo, 0, len(q.Players))
+	for _, name := range q.Players {
+		players = append(players, PlayerInfo{Name: name})
+	}
+	return players
+}
+
+// QueryServer 使用GameSpy4 Query协议(UDP)查询服务器的完整信息
+// 需要服务器开启 enable-query=true，Java版与基岩版均适用
+func QueryServer(ctx context.Context, host string, port int) (*QueryResult, error) {
+	conn, err := dialContext(ctx, "udp", fmt.Sprintf("%s:%d", host, port))
+	if err != nil {
+		return nil, contextError(ctx, fmt.Errorf("连接失败: %w", err))
+	}
+	defer conn.Close()
+
+	// 会话ID每个字节只使用低4位
+	sessionID := rand.Int31() & 0x0F0F0F0F
+
+	latency := newLatency()
+
+	// 握手，获取challenge token，握手往返即为ping耗时
+	startTime := time.Now()
+	if _, err = conn.Write(createQueryPacket(queryTypeHandshake, sessionID, nil)); err != nil {
+		return nil, fmt.Errorf("发送握手包失败: %w", err)
+	}
+
+	buffer := make([]byte, 65536)
+	n, err := conn.Read(buffer)
+	if err != nil {
+		return nil, contextError(ctx, fmt.Errorf("读取握手响应失败: %w", err))
+	}
+	latency.Ping = millis(time.Since(startTime))
+
+	token, err := parseQueryHandshake(buffer[:n], sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 请求完整状态 (token后附加4字节填充)
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint32(payload[0:4], uint32(token))
+	startTime = time.Now()
+	if _, err = conn.Write(createQueryPacket(queryTypeStat, sessionID, payload)); err != nil {
+		return nil, fmt.Errorf("发送状态请求失败: %w", err)
+	}
+
+	n, err = conn.Read(buffer)
+	if err != nil {
+		return nil, contextError(ctx, fmt.Errorf("读取状态响应失败: %w", err))
+	}
+	latency.Status = millis(time.Since(startTime))
+
+	result, err := parseQueryFullStat(buffer[:n], sessionID)
+	if err != nil {
+		return nil, err
+	}
+	result.Latency = latency
+	return result, nil
+}
+
+func init() {
+	RegisterProber(queryProber{})
+}
+
+// queryProber 使用GameSpy4 Query协议探测服务器，适用于关闭了状态ping但开启了Query的服务器
+type queryProber struct{}
+
+func (queryProber) Name() string        { return "query" }
+func (queryProber) Description() string { return "GameSpy4 Query协议 (需开启enable-query)" }
+func (queryProber) DefaultPort() int    { return 25565 }
+
+func (queryProber) Probe(ctx context.Context, target ProbeTarget) (*MinecraftServer, error) {
+	result, err := QueryServer(ctx, target.Host, target.Port)
+	if err != nil {
+		return nil, err
+	}
+
+	serverType, edition := JavaEdition, ReleaseEditionJava
+	if result.GameID == "MINECRAFTPE" {
+		serverType, edition = BedrockEdition, ReleaseEditionBedrock
+	}
+
+	return &MinecraftServer{
+		ServerType: serverType,
+		Version: VersionInfo{
+			Name: result.Version,
+		},
+		Players: Players{
+			Online: result.NumPlayers,
+			Max:    result.MaxPlayers,
+			Sample: result.PlayerInfos(),
+		},
+		Release:     ResolveRelease(edition, 0, result.Version),
+		Description: ParseLegacyText(result.MOTD),
+		Ping:        result.Latency.RoundTrip(),
+		Latency:     result.Latency,
+		Online:      true,
+		RawData:     result,
+	}, nil
+}
+
+// createQueryPacket 创建Query请求包
+func createQueryPacket(packetType byte, sessionID int32, payload []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(queryMagicHigh)
+	buf.WriteByte(queryMagicLow)
+	buf.WriteByte(packetType)
+	binary.Write(&buf, binary.BigEndian, sessionID)
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+// checkQueryHeader 校验Query响应头 (类型 + 会话ID)
+func checkQueryHeader(data []byte, packetType byte, sessionID int32) error {
+	if len(data) < 5 {
+		return parseError("query", "header", ErrTruncated, fmt.Sprintf("%d 字节", len(data)))
+	}
+	if data[0] != packetType {
+		return parseError("query", "packet type", ErrMalformed, fmt.Sprint(data[0]))
+	}
+	if int32(binary.BigEndian.Uint32(data[1:5])) != sessionID {
+		return parseError("query", "session id", ErrMalformed, "会话ID不匹配")
+	}
+	return nil
+}
+
+// parseQueryHandshake 解析握手响应中的challenge token
+func parseQueryHandshake(data []byte, sessionID int32) (int32, error) {
+	if err := checkQueryHeader(data, queryTypeHandshake, sessionID); err != nil {
+		return 0, err
+	}
+
+	tokenStr := strings.TrimRight(string(data[5:]), "\x00")
+	token, err := strconv.ParseInt(tokenStr, 10, 64)
+	if err != nil {
+		return 0, parseError("query", "challenge token", ErrMalformed, err.Error())
+	}
+
+	return int32(token), nil
+}
+
+// parseQueryFullStat 解析完整状态响应
+func parseQueryFullStat(data []byte, sessionID int32) (*QueryResult, error) {
+	if err := checkQueryHeader(data, queryTypeStat, sessionID); err != nil {
+		return nil, err
+	}
+
+	// 跳过包头和11字节的 "splitnum\x00\x80\x00" 填充
+	offset := 5 + 11
+	if len(data) < offset {
+		return nil, parseError("query", "header", ErrTruncated, fmt.Sprintf("%d 字节", len(data)))
+	}
+
+	result := &QueryResult{Raw: make(map[string]string)}
+
+	// 读取以空字节结尾的键值对，直到遇到空键
+	for {
+		key, next, ok := readNullTerminated(data, offset)
+		if !ok {
+			return nil, parseError("query", "key values", ErrTruncated, "")
+		}
+		offset = next
+		if key == "" {
+			break
+		}
+
+		value, next, ok := readNullTerminated(data, offset)
+		if !ok {
+			return nil, parseError("query", "key values", ErrTruncated, "")
+		}
+		offset = next
+		result.Raw[key] = value
+	}
+
+	// 跳过10字节的 "\x01player_\x00\x00" 填充
+	offset += 10
+
+	// 读取玩家列表，直到遇到空名称或数据结束
+	for offset < len(data) {
+		name, next, ok := readNullTerminated(data, offset)
+		if !ok || name == "" {
+			break
+		}
+		offset = next
+		result.Players = append(result.Players, name)
+	}
+
+	result.applyRaw()
+	return result, nil
+}
+
+// applyRaw 将原始键值对映射到结构体字段
+func (q *QueryResult) applyRaw() {
+	q.MOTD = q.Raw["hostname"]
+	q.GameType = q.Raw["gametype"]
+	q.GameID = q.Raw["game_id"]
+	q.Version = q.Raw["version"]
+	q.Map = q.Raw["map"]
+	q.HostIP = q.Raw["hostip"]
+	q.NumPlayers, _ = strconv.Atoi(q.Raw["numplayers"])
+	q.MaxPlayers, _ = strconv.Atoi(q.Raw["maxplayers"])
+	q.HostPort, _ = strconv.Atoi(q.Raw["hostport"])
+
+	// plugins 格式: "CraftBukkit on Bukkit 1.2.5: WorldEdit 5.3; CommandBook 2.1"
+	plugins := q.Raw["plugins"]
+	if software, list, found := strings.Cut(plugins, ":"); found {
+		q.Software = strings.TrimSpace(software)
+		for _, plugin := range strings.Split(list, ";") {
+			if plugin = strings.TrimSpace(plugin); plugin != "" {
+				q.Plugins = append(q.Plugins, plugin)
+			}
+		}
+	} else {
+		q.Software = strings.TrimSpace(plugins)
+	}
+
+	// 部分基岩版服务端 (如PocketMine) 使用 server_engine 字段
+	if q.Software == "" {
+		q.Software = q.Raw["server_engine"]
+	}
+	if q.Software == "" {
+		q.Software = "Vanilla"
+	}
+}
+
+// readNullTerminated 从offset开始读取以空字节结尾的字符串
+func readNullTerminated(data []byte, offset int) (string, int, bool) {
+	if offset >= len(data) {
+		return "", offset, false
+	}
+	end := bytes.IndexByte(data[offset:], 0x00)
+	if end < 0 {
+		return "", offset, false
+	}
+	return string(data[offset : offset+end]), offset + end + 1, true
+}
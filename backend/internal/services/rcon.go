@@ -0,0 +1,219 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// RCON 包类型
+const (
+	rconTypeResponse = 0
+	rconTypeCommand  = 2
+	rconTypeAuth     = 3
+
+	// Minecraft 单个响应包的正文最多4096字节，这里留出足够余量
+	rconMaxPacketSize = 1 << 16
+)
+
+// RconClient Source RCON 协议客户端
+type RconClient struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	requestID int32
+}
+
+// DialRcon 连接RCON端口并使用密码完成认证
+// ctx同时限定了该连接后续所有命令的截止时间，ctx取消时连接会被关闭
+func DialRcon(ctx context.Context, host string, port int, password string) (*RconClient, error) {
+	conn, err := dialContext(ctx, "tcp", fmt.Sprintf("%s:%d", host, port))
+	if err != nil {
+		return nil, contextError(ctx, fmt.Errorf("连接RCON失败: %v", err))
+	}
+
+	client := &RconClient{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+
+	if err := client.authenticate(password); err != nil {
+		conn.Close()
+		return nil, contextError(ctx, err)
+	}
+
+	return client, nil
+}
+
+// Close 关闭RCON连接
+func (r *RconClient) Close() error {
+	return r.conn.Close()
+}
+
+// authenticate 发送认证包并等待认证结果
+func (r *RconClient) authenticate(password string) error {
+	id := r.nextID()
+	if err := r.writePacket(id, rconTypeAuth, password); err != nil {
+		return fmt.Errorf("发送RCON认证包失败: %v", err)
+	}
+
+	// 部分实现会在认证响应前先发送一个空的响应包，跳过即可
+	for {
+		respID, respType, _, err := r.readPacket()
+		if err != nil {
+			return fmt.Errorf("读取RCON认证响应失败: %v", err)
+		}
+		if respType != rconTypeCommand {
+			continue
+		}
+		if respID == -1 || respID != id {
+			return fmt.Errorf("RCON认证失败: 密码错误")
+		}
+		return nil
+	}
+}
+
+// Command 执行命令并返回完整的响应文本
+func (r *RconClient) Command(command string) (string, error) {
+	id := r.nextID()
+	if err := r.writePacket(id, rconTypeCommand, command); err != nil {
+		return "", fmt.Errorf("发送RCON命令失败: %v", err)
+	}
+
+	// 长响应会被拆分为多个包，发送一个哨兵包，收到其回应即表示命令响应已结束
+	sentinelID := r.nextID()
+	if err := r.writePacket(sentinelID, rconTypeResponse, ""); err != nil {
+		return "", fmt.Errorf("发送RCON哨兵包失败: %v", err)
+	}
+
+	var result strings.Builder
+	for {
+		respID, _, body, err := r.readPacket()
+		if err != nil {
+			return "", fmt.Errorf("读取RCON响应失败: %v", err)
+		}
+		if respID == sentinelID {
+			break
+		}
+		if respID == id {
+			result.WriteString(body)
+		}
+	}
+
+	return result.String(), nil
+}
+
+// nextID 生成下一个请求ID
+func (r *RconClient) nextID() int32 {
+	r.requestID++
+	return r.requestID
+}
+
+// writePacket 写入RCON数据包 (小端序: 长度 + ID + 类型 + 正文 + 两个空字节)
+func (r *RconClient) writePacket(id, packetType int32, body string) error {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(4+4+len(body)+2))
+	binary.Write(&buf, binary.LittleEndian, id)
+	binary.Write(&buf, binary.LittleEndian, packetType)
+	buf.WriteString(body)
+	buf.Write([]byte{0x00, 0x00})
+
+	_, err := r.conn.Write(buf.Bytes())
+	return err
+}
+
+// readPacket 读取一个RCON数据包
+func (r *RconClient) readPacket() (int32, int32, string, error) {
+	var size int32
+	if err := binary.Read(r.reader, binary.LittleEndian, &size); err != nil {
+		return 0, 0, "", err
+	}
+	if size < 10 || size > rconMaxPacketSize {
+		return 0, 0, "", fmt.Errorf("无效的RCON包长度: %d", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r.reader, data); err != nil {
+		return 0, 0, "", err
+	}
+
+	id := int32(binary.LittleEndian.Uint32(data[0:4]))
+	packetType := int32(binary.LittleEndian.Uint32(data[4:8]))
+	body := strings.TrimRight(string(data[8:]), "\x00")
+
+	return id, packetType, body, nil
+}
+
+// RconPlayerList 通过RCON执行list命令获取在线玩家列表
+func RconPlayerList(ctx context.Context, host string, port int, password string) ([]PlayerInfo, error) {
+	client, err := DialRcon(ctx, host, port, password)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	resp, err := client.Command("list")
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	return ParseListResponse(resp), nil
+}
+
+// listUUIDPattern 匹配 "list uuids" 输出中的 "玩家名 (UUID)" 格式
+var listUUIDPattern = regexp.MustCompile(`^(\S+)\s+\(([0-9a-fA-F-]{32,36})\)$`)
+
+// ParseListResponse 解析list命令的输出
+// 支持的格式:
+//   - 1.13+: "There are 2 of a max of 20 players online: Steve, Alex"
+//   - 1.12及更早: "There are 2/20 players online:\nSteve, Alex"
+//   - Essentials分组: "There are 2 out of maximum 20 players online.\nadmins: Steve\ndefault: Alex"
+//   - list uuids: "... online: Steve (069a79f4-44e9-4726-a5be-fca90e38aaf5)"
+func ParseListResponse(resp string) []PlayerInfo {
+	lines := strings.Split(StripFormattingCodes(resp), "\n")
+
+	var segments []string
+	if _, names, found := strings.Cut(lines[0], ":"); found {
+		segments = append(segments, names)
+	}
+	for _, line := range lines[1:] {
+		// Essentials 分组行: "分组名: 玩家1, 玩家2"
+		if _, names, found := strings.Cut(line, ":"); found {
+			line = names
+		}
+		segments = append(segments, line)
+	}
+
+	var players []PlayerInfo
+	seen := make(map[string]bool)
+	for _, segment := range segments {
+		for _, entry := range strings.Split(segment, ",") {
+			entry = strings.TrimSpace(entry)
+			// 去除 Essentials 的状态前缀
+			entry = strings.TrimPrefix(entry, "[AFK]")
+			entry = strings.TrimPrefix(entry, "[HIDDEN]")
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+
+			player := PlayerInfo{Name: entry}
+			if match := listUUIDPattern.FindStringSubmatch(entry); match != nil {
+				player = PlayerInfo{Name: match[1], ID: match[2]}
+			}
+
+			if strings.ContainsAny(player.Name, " \t") || seen[player.Name] {
+				continue
+			}
+			seen[player.Name] = true
+			players = append(players, player)
+		}
+	}
+
+	return players
+}
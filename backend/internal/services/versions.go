@@ -0,0 +1,77 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// 协议版本表中的版本类型
+const (
+	ReleaseEditionJava    = "java"
+	ReleaseEditionLegacy  = "legacy" // 1.6及更早的旧版ping协议
+	ReleaseEditionBedrock = "bedrock"
+)
+
+// javaSnapshotProtocolBit Java版快照版本的协议号带有此标志位
+const javaSnapshotProtocolBit = 0x40000000
+
+// protocolVersionsJSON 协议号与正式版本的对应表，新版本发布时更新该文件即可
+//
+//go:embed data/protocol_versions.json
+var protocolVersionsJSON []byte
+
+// protocolRelease 同一协议号对应的正式版本
+type protocolRelease struct {
+	Protocol int      `json:"protocol"`
+	Releases []string `json:"releases"`
+}
+
+// protocolReleases 按版本类型和协议号索引的版本名称
+var protocolReleases = loadProtocolReleases()
+
+// releaseNamePattern 从版本名称中提取版本号 (如 "Paper 1.20.4" 中的 "1.20.4")
+var releaseNamePattern = regexp.MustCompile(`\b1\.\d+(?:\.\d+)?\b`)
+
+// loadProtocolReleases 解析内嵌的协议版本表
+func loadProtocolReleases() map[string]map[int]string {
+	var table map[string][]protocolRelease
+	if err := json.Unmarshal(protocolVersionsJSON, &table); err != nil {
+		panic(fmt.Sprintf("解析协议版本表失败: %v", err))
+	}
+
+	result := make(map[string]map[int]string, len(table))
+	for edition, entries := range table {
+		byProtocol := make(map[int]string, len(entries))
+		for _, entry := range entries {
+			byProtocol[entry.Protocol] = formatReleaseRange(entry.Releases)
+		}
+		result[edition] = byProtocol
+	}
+	return result
+}
+
+// formatReleaseRange 将同一协议号的多个版本格式化为范围，如 "1.21-1.21.1"
+func formatReleaseRange(releases []string) string {
+	switch len(releases) {
+	case 0:
+		return ""
+	case 1:
+		return releases[0]
+	default:
+		return releases[0] + "-" + releases[len(releases)-1]
+	}
+}
+
+// ResolveRelease 将协议号映射为正式版本名称
+// 协议号未收录时尝试从服务器上报的版本名称中提取版本号，仍无法识别时返回空字符串
+func ResolveRelease(edition string, protocol int, versionName string) string {
+	if edition == ReleaseEditionJava && protocol&javaSnapshotProtocolBit != 0 {
+		return "snapshot"
+	}
+	if release, ok := protocolReleases[edition][protocol]; ok {
+		return release
+	}
+	return releaseNamePattern.FindString(versionName)
+}
@@ -0,0 +1,238 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"etamonitor/internal/events"
+)
+
+// Discord嵌入的颜色
+const (
+	colorGreen  = 0x57F287
+	colorRed    = 0xED4245
+	colorYellow = 0xFEE75C
+	colorBlue   = 0x5865F2
+	colorGrey   = 0x95A5A6
+)
+
+// discordMessage Discord Webhook的消息体
+type discordMessage struct {
+	Username string         `json:"username"`
+	Embeds   []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	URL         string         `json:"url,omitempty"`
+	Color       int            `json:"color"`
+	Timestamp   string         `json:"timestamp"`
+	Author      *discordAuthor `json:"author,omitempty"`
+	Thumbnail   *discordImage  `json:"thumbnail,omitempty"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Footer      *discordFooter `json:"footer,omitempty"`
+}
+
+type discordAuthor struct {
+	Name    string `json:"name"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
+type discordImage struct {
+	URL string `json:"url"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordFooter struct {
+	Text    string `json:"text"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
+// eventData 生成Discord消息所需的事件字段，各类事件只包含其中一部分
+type eventData struct {
+	Name            string `json:"name"`
+	Status          string `json:"status"`
+	PreviousStatus  string `json:"previous_status"`
+	PlayersOnline   int    `json:"players_online"`
+	Ping            int    `json:"ping"`
+	Version         string `json:"version"`
+	FailureCategory string `json:"failure_category"`
+	FailureMessage  string `json:"failure_message"`
+	Username        string `json:"username"`
+	ServerName      string `json:"server_name"`
+	Avatar          string `json:"avatar"`
+	SessionDuration int    `json:"session_duration"`
+	Title           string `json:"title"`
+	Message         string `json:"message"`
+	Duration        int64  `json:"duration"`
+	Rule            *struct {
+		Name string `json:"name"`
+	} `json:"rule"`
+	Server *struct {
+		Name string `json:"name"`
+	} `json:"server"`
+}
+
+// discordPayload 将事件转换为Discord消息
+// publicURL 为空时消息中不包含服务器图标和链接，faviconHash 为空时不包含服务器图标
+func discordPayload(event events.Event, publicURL, faviconHash string) ([]byte, error) {
+	raw, err := json.Marshal(event.Data)
+	if err != nil {
+		return nil, err
+	}
+	var data eventData
+	json.Unmarshal(raw, &data)
+
+	embed := discordEmbed{
+		Color:     colorGrey,
+		Timestamp: event.Timestamp.Format(time.RFC3339),
+	}
+
+	serverName := data.ServerName
+	switch {
+	case serverName == "" && data.Server != nil:
+		serverName = data.Server.Name
+	case serverName == "":
+		serverName = data.Name
+	}
+	var faviconURL string
+	if publicURL != "" && event.ServerID != 0 {
+		// 带上图标哈希，图标变化后Discord不会继续使用缓存的旧图标
+		if faviconHash != "" {
+			faviconURL = fmt.Sprintf("%s/api/servers/%d/favicon.png?v=%s", publicURL, event.ServerID, faviconHash)
+		}
+		embed.URL = fmt.Sprintf("%s/server/%d", publicURL, event.ServerID)
+	}
+	if serverName != "" {
+		embed.Footer = &discordFooter{Text: serverName, IconURL: faviconURL}
+	}
+
+	switch event.Type {
+	case events.ServerStatusChanged:
+		embed.Title = fmt.Sprintf("%s %s", data.Name, statusText(data.Status))
+		embed.Color = statusColor(data.Status)
+		if faviconURL != "" {
+			embed.Thumbnail = &discordImage{URL: faviconURL}
+		}
+		if data.Status == "online" || data.Status == "degraded" {
+			embed.Fields = []discordField{
+				{Name: "在线玩家", Value: fmt.Sprintf("%d", data.PlayersOnline), Inline: true},
+				{Name: "延迟", Value: fmt.Sprintf("%dms", data.Ping), Inline: true},
+			}
+			if data.Version != "" {
+				embed.Fields = append(embed.Fields, discordField{Name: "版本", Value: data.Version, Inline: true})
+			}
+		} else if data.FailureMessage != "" {
+			embed.Description = fmt.Sprintf("`%s` %s", data.FailureCategory, data.FailureMessage)
+		}
+
+	case events.PlayerJoin, events.PlayerLeave:
+		embed.Author = &discordAuthor{Name: data.Username, IconURL: data.Avatar}
+		embed.Thumbnail = &discordImage{URL: data.Avatar}
+		embed.Fields = []discordField{
+			{Name: "在线玩家", Value: fmt.Sprintf("%d", data.PlayersOnline), Inline: true},
+		}
+		if event.Type == events.PlayerJoin {
+			embed.Title = fmt.Sprintf("%s 加入了 %s", data.Username, data.ServerName)
+			embed.Color = colorGreen
+		} else {
+			embed.Title = fmt.Sprintf("%s 离开了 %s", data.Username, data.ServerName)
+			embed.Color = colorGrey
+			embed.Fields = append(embed.Fields, discordField{
+				Name: "本次在线", Value: formatDuration(time.Duration(data.SessionDuration) * time.Second), Inline: true,
+			})
+		}
+
+	case events.PlayerTitleAwarded:
+		embed.Title = fmt.Sprintf("%s 获得了称号「%s」", data.Username, data.Title)
+		embed.Color = colorYellow
+		embed.Author = &discordAuthor{Name: data.Username, IconURL: data.Avatar}
+		embed.Thumbnail = &discordImage{URL: data.Avatar}
+
+	case events.AlertFiring, events.AlertResolved:
+		name := ""
+		if data.Rule != nil {
+			name = data.Rule.Name
+		}
+		embed.Description = data.Message
+		if event.Type == events.AlertFiring {
+			embed.Title = "告警: " + name
+			embed.Color = colorRed
+		} else {
+			embed.Title = "告警恢复: " + name
+			embed.Color = colorGreen
+		}
+
+	case events.IncidentOpened:
+		embed.Title = fmt.Sprintf("%s 停机", serverName)
+		embed.Description = fmt.Sprintf("`%s` %s", data.FailureCategory, data.FailureMessage)
+		embed.Color = colorRed
+
+	case events.IncidentResolved:
+		embed.Title = fmt.Sprintf("%s 停机结束", serverName)
+		embed.Description = "持续 " + formatDuration(time.Duration(data.Duration)*time.Second)
+		embed.Color = colorGreen
+
+	case TestEvent:
+		embed.Title = "etaMonitor 测试消息"
+		embed.Description = "Discord 通知已配置成功"
+		embed.Color = colorBlue
+
+	default:
+		embed.Title = event.Type
+	}
+
+	return json.Marshal(discordMessage{Username: "etaMonitor", Embeds: []discordEmbed{embed}})
+}
+
+// statusText 服务器状态的中文描述
+func statusText(status string) string {
+	switch status {
+	case "online":
+		return "已上线"
+	case "offline":
+		return "已离线"
+	case "degraded":
+		return "状态不稳定"
+	case "maintenance":
+		return "维护中"
+	case "paused":
+		return "已暂停监控"
+	default:
+		return "状态变为 " + status
+	}
+}
+
+func statusColor(status string) int {
+	switch status {
+	case "online":
+		return colorGreen
+	case "offline":
+		return colorRed
+	case "degraded", "maintenance":
+		return colorYellow
+	default:
+		return colorGrey
+	}
+}
+
+// formatDuration 格式化为 "1小时5分钟" 形式
+func formatDuration(d time.Duration) string {
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	switch {
+	case hours > 0:
+		return fmt.Sprintf("%d小时%d分钟", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%d分钟", minutes)
+	default:
+		return fmt.Sprintf("%d秒", int(d.Seconds()))
+	}
+}
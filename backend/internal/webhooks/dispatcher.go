@@ -0,0 +1,427 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"etamonitor/internal/auth"
+	"etamonitor/internal/events"
+	"etamonitor/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 投递状态
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+const (
+	queueSize         = 256              // 等待分发的事件数量上限，超出时丢弃事件
+	maxConcurrent     = 4                // 同时进行的投递请求数量
+	maxAttempts       = 5                // 每次投递的最多尝试次数
+	initialBackoff    = 2 * time.Second  // 第一次重试前的等待时间，之后每次翻倍
+	maxRetryAfter     = 5 * time.Minute  // 服务端要求的重试等待时间上限
+	requestTimeout    = 10 * time.Second // 单次请求超时
+	deliveryRetention = 7 * 24 * time.Hour
+)
+
+// Dispatcher 订阅监控事件并投递给匹配的Webhook，失败时按指数退避重试
+type Dispatcher struct {
+	db            *gorm.DB
+	encryptionKey string
+	publicURL     string
+	client        *http.Client
+	queue         chan events.Event
+	semaphore     chan struct{}
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+
+	limitMu sync.Mutex
+	limits  map[string]*rateLimit
+}
+
+// rateLimit 单个地址的速率限制，同一地址的请求依次发送
+// 没有投递在使用且限制已过期时从 Dispatcher.limits 中删除
+type rateLimit struct {
+	mu    sync.Mutex
+	until time.Time // 在此时间之前不向该地址发送请求
+	users int       // 正在使用的投递数量，由 Dispatcher.limitMu 保护
+}
+
+// NewDispatcher 创建Webhook分发器，encryptionKey 用于解密签名密钥
+// publicURL 为外部访问地址，用于Discord消息中的链接和图标
+func NewDispatcher(db *gorm.DB, encryptionKey, publicURL string) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		db:            db,
+		encryptionKey: encryptionKey,
+		publicURL:     publicURL,
+		client:        &http.Client{Timeout: requestTimeout},
+		queue:         make(chan events.Event, queueSize),
+		semaphore:     make(chan struct{}, maxConcurrent),
+		ctx:           ctx,
+		cancel:        cancel,
+		limits:        make(map[string]*rateLimit),
+	}
+}
+
+// Start 订阅事件并开始分发，直到调用Stop
+func (d *Dispatcher) Start() {
+	events.Subscribe(d.enqueue)
+
+	cleanupTicker := time.NewTicker(time.Hour)
+	defer cleanupTicker.Stop()
+	d.cleanupDeliveries()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			d.wg.Wait()
+			log.Println("Webhook dispatcher stopped")
+			return
+		case event := <-d.queue:
+			d.dispatch(event)
+		case <-cleanupTicker.C:
+			d.cleanupDeliveries()
+			d.pruneRateLimits()
+		}
+	}
+}
+
+// Stop 停止分发，等待重试中的投递放弃后返回
+func (d *Dispatcher) Stop() {
+	d.cancel()
+}
+
+// enqueue 接收事件，不阻塞发布者
+func (d *Dispatcher) enqueue(event events.Event) {
+	select {
+	case d.queue <- event:
+	default:
+		log.Printf("Webhook queue is full, event %s (%s) dropped", event.ID, event.Type)
+	}
+}
+
+// dispatch 为每个匹配的Webhook创建投递记录并开始投递
+func (d *Dispatcher) dispatch(event events.Event) {
+	var webhooks []models.Webhook
+	if err := d.db.Where("enabled = ?", true).Find(&webhooks).Error; err != nil {
+		log.Printf("Failed to load webhooks: %v", err)
+		return
+	}
+
+	for i := range webhooks {
+		webhook := webhooks[i]
+		if !Matches(&webhook, event) {
+			continue
+		}
+
+		delivery, err := newDelivery(d.db, &webhook, event, d.publicURL)
+		if err != nil {
+			log.Printf("Failed to record delivery of %s to webhook %s: %v", event.Type, webhook.Name, err)
+			continue
+		}
+
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.deliver(&webhook, delivery)
+		}()
+	}
+}
+
+// deliver 投递事件，可重试的失败按指数退避重试，直到成功或达到最多尝试次数
+func (d *Dispatcher) deliver(webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	secret, err := auth.DecryptSecret(webhook.Secret, d.encryptionKey)
+	if err != nil {
+		finish(d.db, delivery, StatusFailed, 0, fmt.Sprintf("解密签名密钥失败: %v", err), 0)
+		return
+	}
+
+	limit := d.rateLimit(webhook.URL)
+	defer d.releaseRateLimit(webhook.URL, limit)
+	backoff := initialBackoff
+	for {
+		limit.mu.Lock()
+		if !limit.wait(d.ctx) {
+			limit.mu.Unlock()
+			finish(d.db, delivery, StatusFailed, 0, "服务停止，已放弃投递", 0)
+			return
+		}
+		d.semaphore <- struct{}{}
+		result := send(d.ctx, d.client, webhook.URL, secret, delivery)
+		<-d.semaphore
+		limit.update(result)
+		limit.mu.Unlock()
+
+		switch {
+		case result.ok():
+			finish(d.db, delivery, StatusSucceeded, result.statusCode, "", result.duration)
+			return
+		case !result.retryable() || delivery.Attempts >= maxAttempts:
+			finish(d.db, delivery, StatusFailed, result.statusCode, result.message(), result.duration)
+			log.Printf("Delivery %d of %s to webhook %s failed after %d attempts: %s",
+				delivery.ID, delivery.EventType, webhook.Name, delivery.Attempts, result.message())
+			return
+		}
+		finish(d.db, delivery, StatusPending, result.statusCode, result.message(), result.duration)
+
+		wait := backoff
+		if result.retryAfter > wait {
+			wait = min(result.retryAfter, maxRetryAfter)
+		}
+		backoff *= 2
+
+		select {
+		case <-d.ctx.Done():
+			finish(d.db, delivery, StatusFailed, result.statusCode, "服务停止，已放弃重试", result.duration)
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// rateLimit 返回地址的速率限制状态，使用结束后需调用 releaseRateLimit
+func (d *Dispatcher) rateLimit(url string) *rateLimit {
+	d.limitMu.Lock()
+	defer d.limitMu.Unlock()
+
+	limit, ok := d.limits[url]
+	if !ok {
+		limit = &rateLimit{}
+		d.limits[url] = limit
+	}
+	limit.users++
+	return limit
+}
+
+// releaseRateLimit 投递结束时释放速率限制状态，没有其他投递使用且限制已过期时删除
+func (d *Dispatcher) releaseRateLimit(url string, limit *rateLimit) {
+	d.limitMu.Lock()
+	defer d.limitMu.Unlock()
+
+	limit.users--
+	if limit.users == 0 && !time.Now().Before(limit.until) {
+		delete(d.limits, url)
+	}
+}
+
+// pruneRateLimits 删除无人使用且已过期的速率限制状态
+func (d *Dispatcher) pruneRateLimits() {
+	d.limitMu.Lock()
+	defer d.limitMu.Unlock()
+
+	now := time.Now()
+	for url, limit := range d.limits {
+		if limit.users == 0 && !now.Before(limit.until) {
+			delete(d.limits, url)
+		}
+	}
+}
+
+// wait 等待速率限制解除，服务停止时返回false，调用方需持有 mu
+func (l *rateLimit) wait(ctx context.Context) bool {
+	wait := time.Until(l.until)
+	if wait <= 0 {
+		return true
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(min(wait, maxRetryAfter)):
+		return true
+	}
+}
+
+// update 根据响应记录速率限制，调用方需持有 mu
+// 收到429或剩余请求数为0 (Discord的 X-RateLimit-Remaining) 时，在重置之前暂停向该地址发送
+func (l *rateLimit) update(result sendResult) {
+	var wait time.Duration
+	switch {
+	case result.statusCode == http.StatusTooManyRequests:
+		wait = result.retryAfter
+	case result.rateLimitExhausted:
+		wait = result.resetAfter
+	}
+	if wait > 0 {
+		l.until = time.Now().Add(min(wait, maxRetryAfter))
+	}
+}
+
+// SendTest 向Webhook发送一次测试事件，不进行重试，返回投递记录
+func SendTest(ctx context.Context, db *gorm.DB, encryptionKey, publicURL string, webhook *models.Webhook) (*models.WebhookDelivery, error) {
+	secret, err := auth.DecryptSecret(webhook.Secret, encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("解密签名密钥失败: %w", err)
+	}
+
+	event := events.New(TestEvent, 0, map[string]interface{}{"webhook_id": webhook.ID, "name": webhook.Name})
+	delivery, err := newDelivery(db, webhook, event, publicURL)
+	if err != nil {
+		return nil, err
+	}
+
+	result := send(ctx, &http.Client{Timeout: requestTimeout}, webhook.URL, secret, delivery)
+	status := StatusFailed
+	if result.ok() {
+		status = StatusSucceeded
+	}
+	finish(db, delivery, status, result.statusCode, result.message(), result.duration)
+	return delivery, nil
+}
+
+// newDelivery 按Webhook类型生成请求体，创建待投递的记录
+func newDelivery(db *gorm.DB, webhook *models.Webhook, event events.Event, publicURL string) (*models.WebhookDelivery, error) {
+	var payload []byte
+	var err error
+	if webhook.Type == TypeDiscord {
+		var faviconHash string
+		if publicURL != "" && event.ServerID != 0 {
+			db.Model(&models.Server{}).Select("favicon_hash").Where("id = ?", event.ServerID).Scan(&faviconHash)
+		}
+		payload, err = discordPayload(event, publicURL, faviconHash)
+	} else {
+		payload, err = json.Marshal(event)
+	}
+	if err != nil {
+		return nil, err
+	}
+	delivery := &models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		EventType: event.Type,
+		ServerID:  event.ServerID,
+		Payload:   payload,
+		Status:    StatusPending,
+	}
+	if err := db.Create(delivery).Error; err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// finish 保存一次尝试的结果
+func finish(db *gorm.DB, delivery *models.WebhookDelivery, status string, statusCode int, message string, duration time.Duration) {
+	if err := db.Model(delivery).Updates(map[string]interface{}{
+		"status":      status,
+		"status_code": statusCode,
+		"error":       message,
+		"duration":    duration.Milliseconds(),
+		"attempts":    delivery.Attempts,
+	}).Error; err != nil {
+		log.Printf("Failed to update webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// sendResult 一次请求的结果
+type sendResult struct {
+	statusCode         int
+	body               string
+	err                error
+	retryAfter         time.Duration // 429响应要求的等待时间
+	rateLimitExhausted bool          // 在重置之前不能再发送请求
+	resetAfter         time.Duration // 速率限制重置前的时间
+	duration           time.Duration
+}
+
+func (r sendResult) ok() bool {
+	return r.err == nil && r.statusCode >= 200 && r.statusCode < 300
+}
+
+// retryable 网络错误、429和5xx响应可以重试
+func (r sendResult) retryable() bool {
+	return r.err != nil || r.statusCode == http.StatusTooManyRequests || r.statusCode >= 500
+}
+
+func (r sendResult) message() string {
+	switch {
+	case r.err != nil:
+		return r.err.Error()
+	case r.ok():
+		return ""
+	case r.body != "":
+		return fmt.Sprintf("HTTP %d: %s", r.statusCode, r.body)
+	default:
+		return fmt.Sprintf("HTTP %d", r.statusCode)
+	}
+}
+
+// send 发送一次签名的POST请求
+func send(ctx context.Context, client *http.Client, url, secret string, delivery *models.WebhookDelivery) sendResult {
+	delivery.Attempts++
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return sendResult{err: err}
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "etaMonitor-Webhook")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	if secret != "" {
+		req.Header.Set(HeaderSignature, Sign(secret, timestamp, delivery.Payload))
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return sendResult{err: err, duration: time.Since(start)}
+	}
+	defer resp.Body.Close()
+
+	// 只保留响应开头用于排查问题
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	result := sendResult{
+		statusCode: resp.StatusCode,
+		body:       string(bytes.TrimSpace(body)),
+		duration:   time.Since(start),
+	}
+	result.retryAfter = parseSeconds(resp.Header.Get("Retry-After"))
+	if resp.StatusCode == http.StatusTooManyRequests {
+		// Discord在响应体中给出更精确的等待时间
+		var limited struct {
+			RetryAfter float64 `json:"retry_after"`
+		}
+		if json.Unmarshal(body, &limited) == nil && limited.RetryAfter > 0 {
+			result.retryAfter = time.Duration(limited.RetryAfter * float64(time.Second))
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		result.rateLimitExhausted = true
+		result.resetAfter = parseSeconds(resp.Header.Get("X-RateLimit-Reset-After"))
+	}
+	return result
+}
+
+// parseSeconds 解析以秒为单位的时间，可以带小数
+func parseSeconds(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// cleanupDeliveries 删除过期的投递记录
+func (d *Dispatcher) cleanupDeliveries() {
+	result := d.db.Where("created_at < ?", time.Now().Add(-deliveryRetention)).Delete(&models.WebhookDelivery{})
+	if result.Error != nil {
+		log.Printf("Failed to clean up webhook deliveries: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("Cleaned up %d old webhook deliveries", result.RowsAffected)
+	}
+}
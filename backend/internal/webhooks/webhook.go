@@ -0,0 +1,125 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"etamonitor/internal/events"
+	"etamonitor/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 投递请求附带的请求头
+const (
+	HeaderEvent     = "X-EtaMonitor-Event"
+	HeaderDelivery  = "X-EtaMonitor-Delivery"
+	HeaderTimestamp = "X-EtaMonitor-Timestamp"
+	HeaderSignature = "X-EtaMonitor-Signature"
+)
+
+// TestEvent 手动测试Webhook时发送的事件类型
+const TestEvent = "webhook.test"
+
+// Webhook类型
+const (
+	TypeGeneric = "generic" // 发送签名的事件JSON
+	TypeDiscord = "discord" // 发送Discord消息嵌入
+)
+
+// Validate 校验Webhook的地址和事件类型
+func Validate(webhook *models.Webhook) error {
+	if webhook.Name == "" {
+		return fmt.Errorf("名称不能为空")
+	}
+	u, err := url.Parse(webhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("无效的Webhook地址: %s", webhook.URL)
+	}
+	switch webhook.Type {
+	case "":
+		webhook.Type = TypeGeneric
+	case TypeGeneric, TypeDiscord:
+	default:
+		return fmt.Errorf("不支持的Webhook类型: %s", webhook.Type)
+	}
+	for _, eventType := range webhook.Events {
+		if !events.IsValidType(eventType) {
+			return fmt.Errorf("不支持的事件类型: %s", eventType)
+		}
+	}
+	return nil
+}
+
+// Matches 判断Webhook是否接收该事件
+func Matches(webhook *models.Webhook, event events.Event) bool {
+	if len(webhook.Events) > 0 && !contains(webhook.Events, event.Type) {
+		return false
+	}
+	if len(webhook.ServerIDs) > 0 && !contains(webhook.ServerIDs, event.ServerID) {
+		return false
+	}
+	return true
+}
+
+// RemoveServer 从所有Webhook的服务器筛选中移除被删除的服务器
+// 筛选中只有该服务器的Webhook会被停用，避免变为接收所有服务器的事件
+func RemoveServer(db *gorm.DB, serverID uint) error {
+	var hooks []models.Webhook
+	if err := db.Find(&hooks).Error; err != nil {
+		return err
+	}
+	for i := range hooks {
+		hook := &hooks[i]
+		if !contains(hook.ServerIDs, serverID) {
+			continue
+		}
+		var remaining []uint
+		for _, id := range hook.ServerIDs {
+			if id != serverID {
+				remaining = append(remaining, id)
+			}
+		}
+		hook.ServerIDs = remaining
+		if len(remaining) == 0 {
+			hook.Enabled = false
+		}
+		if err := db.Model(hook).Select("server_ids", "enabled").Updates(hook).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sign 计算请求签名: HMAC-SHA256(secret, "<timestamp>.<body>")
+// 签名包含时间戳，接收方可据此拒绝重放的请求
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateSecret 生成随机的签名密钥
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成签名密钥失败: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func contains[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+// 服务器状态的显示文字
+const statusTexts = {
+  online: '在线',
+  degraded: '不稳定',
+  offline: '离线',
+  maintenance: '维护中',
+  paused: '已暂停',
+  checking: '检测中'
+}
+
+export const serverStatusText = (status) => statusTexts[status] || '离线'
+
+// 服务器是否可访问 (在线或不稳定)
+export const isServerUp = (status) => status === 'online' || status === 'degraded'